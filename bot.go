@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//bot answers commands using food truck data from source
+type bot struct {
+	source provider.Provider
+	//offices listings show the walking distance from, the nearest one is used
+	offices []office
+	//sortByDistance lists the locations closest to an office first
+	sortByDistance bool
+	//commands counts the commands answered by name, nil counts nothing
+	commands *prometheus.CounterVec
+}
+
+//newBot creates a bot answering from source
+func newBot(source provider.Provider) *bot {
+	return &bot{source: source}
+}
+
+//respond returns the answer to a command, text is the message without the @mention prefix
+func (b *bot) respond(ctx context.Context, text string) string {
+	command, handler := b.route(text)
+	if b.commands != nil {
+		b.commands.WithLabelValues(command).Inc()
+	}
+	ctx = withFields(ctx, "command", command)
+	start := time.Now()
+	response := handler(ctx)
+	logger(ctx).Info("Answered command", "duration", time.Since(start), "response_bytes", len(response))
+	return response
+}
+
+//route finds the handler of a command and names the command for logs and metrics, commands the
+//bot does not know are unknown
+func (b *bot) route(text string) (string, func(ctx context.Context) string) {
+	text = strings.TrimSpace(text)
+	text = strings.ToLower(text)
+
+	if text == "help" {
+		response := fmt.Sprintf("%s: \n", "*You can ask me*")
+		response += fmt.Sprintf("%s \n", "• *show neighborhoods* - to see neighborhoods served")
+		response += fmt.Sprintf("%s \n", "• *show locations in <neighborhood>* - to see food truck locations in a neighborhood")
+		response += fmt.Sprintf("%s \n", "• *show trucks at <location>* - to see food trucks at a location")
+		response += fmt.Sprintf("%s \n", "• *truck <name>* - to see a food truck's profile")
+		response += fmt.Sprintf("%s \n", "• *where is <truck>* - to see where a food truck is this week")
+		response += fmt.Sprintf("%s \n", "• *trucks near <location|lat,long> within <distance>* - to see today's food trucks close by")
+		response += fmt.Sprintf("%s \n", "• *cache stats* - to see how often I answer from my cache")
+		return "help", func(context.Context) string { return response }
+	} else if text == "show neighborhoods" {
+		return "show neighborhoods", b.showNeighborhoods
+	} else if strings.Contains(text, "show locations") {
+		return "show locations", func(ctx context.Context) string { return b.showLocations(ctx, text) }
+	} else if strings.Contains(text, "show trucks") {
+		return "show trucks", func(ctx context.Context) string { return b.showTrucks(ctx, text) }
+	} else if strings.HasPrefix(text, "truck ") {
+		return "truck", func(ctx context.Context) string { return b.showTruck(ctx, strings.TrimPrefix(text, "truck ")) }
+	} else if strings.HasPrefix(text, "where is ") {
+		return "where is", func(ctx context.Context) string { return b.whereIs(ctx, strings.TrimPrefix(text, "where is ")) }
+	} else if strings.HasPrefix(text, "trucks near") {
+		return "trucks near", func(ctx context.Context) string { return b.trucksNear(ctx, strings.TrimPrefix(text, "trucks near")) }
+	} else if text == "cache stats" {
+		return "cache stats", func(context.Context) string { return b.showCacheStats() }
+	}
+	return "unknown", func(context.Context) string {
+		return "Sorry I cannot help you with this, please try help to see things you can ask me"
+	}
+}
+
+func (b *bot) showNeighborhoods(ctx context.Context) string {
+	var message string
+	neighborhoods, err := b.source.Neighborhoods(ctx)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Showing partial list of neighborhoods", "error", err)
+	} else if err != nil {
+		return errorMessage(ctx, err)
+	}
+	if len(neighborhoods) == 0 {
+		return fmt.Sprintf("%s \n", "No Neighborhoods found")
+	}
+	//show all neighborhoods
+	message = fmt.Sprintf("%s \n", "*You can find food trucks in following neighborhoods*")
+	for _, n := range neighborhoods {
+		message += fmt.Sprintf("• %s \n", n.ID)
+	}
+	return message
+}
+
+func (b *bot) showLocations(ctx context.Context, text string) string {
+	var message string
+	name, ok := argumentAfter(text, "in")
+	if !ok {
+		return "Missing Neighborhood"
+	}
+	if len(name) == 0 {
+		return "Missing neighborhood"
+	}
+	n, message := b.resolveNeighborhood(ctx, name, "show locations in")
+	if n == nil {
+		return message
+	}
+	neighborhood := n.ID
+	locations, err := b.source.Locations(ctx, neighborhood)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Showing partial list of locations", "error", err)
+	} else if err != nil {
+		return errorMessage(ctx, err)
+	}
+	if len(locations) == 0 {
+		return fmt.Sprintf("No locations found at %s neighborhood \n", neighborhood)
+	}
+	if b.sortByDistance {
+		sort.SliceStable(locations, func(i, j int) bool {
+			return b.officeDistance(locationPoint(locations[i])) < b.officeDistance(locationPoint(locations[j]))
+		})
+	}
+	message = fmt.Sprintf("%s \n", "*You can find food trucks in following locations*")
+	for _, l := range locations {
+		message += fmt.Sprintf("• %s - %v%s \n", l.Name, l.UID, b.distanceNote(locationPoint(l)))
+	}
+	return message
+}
+
+func (b *bot) showTrucks(ctx context.Context, text string) string {
+	//extract location name or id from text
+	locString, ok := argumentAfter(text, "at")
+	if !ok || len(locString) == 0 {
+		return "Missing location"
+	}
+	location, message := b.resolveLocation(ctx, locString, "show trucks at")
+	if location == nil {
+		return message
+	}
+	return b.getTrucksForLocation(ctx, *location)
+}
+
+func (b *bot) showTruck(ctx context.Context, name string) string {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return "Missing truck name"
+	}
+	match, message := b.resolveTruck(ctx, name, "truck")
+	if match == nil {
+		return message
+	}
+	truck, err := b.source.Truck(ctx, match.ID)
+	if err != nil {
+		return errorMessage(ctx, err)
+	}
+	return b.truckProfile(truck)
+}
+
+//resolveTruck finds the truck called name among all known trucks. When there is no single match it
+//returns nil and a message listing the candidates, suggesting command to pick one
+func (b *bot) resolveTruck(ctx context.Context, name string, command string) (*seattlefoodtruck.Truck, string) {
+	trucks, err := b.source.Trucks(ctx)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Searching partial list of trucks", "error", err)
+	} else if err != nil {
+		return nil, errorMessage(ctx, err)
+	}
+	matches := matchTrucks(trucks, name)
+	if len(matches) == 0 {
+		return nil, fmt.Sprintf("No food truck named %s", name)
+	}
+	if len(matches) > 1 {
+		var candidates []candidate
+		for _, t := range matches {
+			candidates = append(candidates, candidate{t.Name, t.ID})
+		}
+		return nil, candidatesMessage(name, command, candidates)
+	}
+	return &matches[0], ""
+}
+
+//whereIs lists where the truck called name is booked over the coming week
+func (b *bot) whereIs(ctx context.Context, name string) string {
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), "this week"))
+	if len(name) == 0 {
+		return "Missing truck name"
+	}
+	truck, message := b.resolveTruck(ctx, name, "where is")
+	if truck == nil {
+		return message
+	}
+	now := time.Now()
+	weekEnd := now.AddDate(0, 0, 7)
+	req := seattlefoodtruck.NewTruckEventsRequest(truck.ID, 1)
+	allEvents, err := b.source.Events(ctx, req.Query().Between(now, weekEnd))
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Showing partial list of events", "error", err)
+	} else if err != nil {
+		return errorMessage(ctx, err)
+	}
+	var upcoming []seattlefoodtruck.Event
+	for _, e := range allEvents {
+		if e.EndTime.After(now) && e.StartTime.Before(weekEnd) {
+			upcoming = append(upcoming, e)
+		}
+	}
+	if len(upcoming) == 0 {
+		return fmt.Sprintf("*%s* has no bookings this week", truck.Name)
+	}
+	sort.Slice(upcoming, func(i, j int) bool { return upcoming[i].StartTime.Before(upcoming[j].StartTime) })
+	message = fmt.Sprintf("*%s* this week \n", truck.Name)
+	for _, e := range upcoming {
+		st := e.LocalStart()
+		message += fmt.Sprintf("• %v %v %v %v - %v at *%s* %s \n", st.Weekday(), st.Month(), st.Day(),
+			st.Format(time.Kitchen), e.LocalEnd().Format(time.Kitchen), e.Location.Name, e.Location.FilteredAddress)
+	}
+	return message
+}
+
+//truckProfile formats a truck's profile as a slack message
+func (b *bot) truckProfile(t seattlefoodtruck.Truck) string {
+	message := fmt.Sprintf("*%s* (%s) \n", t.Name, strings.Join(t.FoodCategories, ", "))
+	if t.Trailer {
+		message += "Trailer \n"
+	}
+	if len(t.Description) != 0 {
+		message += fmt.Sprintf("%s \n", t.Description)
+	}
+	links := []struct{ label, value string }{
+		{"Website", t.Website},
+		{"Facebook", t.Facebook},
+		{"Twitter", t.Twitter},
+		{"Instagram", t.Instagram},
+	}
+	for _, l := range links {
+		if len(l.value) != 0 {
+			message += fmt.Sprintf("• %s: %s \n", l.label, l.value)
+		}
+	}
+	photos := t.Photos
+	if len(photos) == 0 && len(t.FeaturedPhoto) != 0 {
+		photos = []string{t.FeaturedPhoto}
+	}
+	for _, p := range photos {
+		message += fmt.Sprintf("%v \n", b.source.PhotoURL(p))
+	}
+	return message
+}
+
+//matchTrucks returns the trucks whose id or name matches name, see matchNames
+func matchTrucks(trucks []seattlefoodtruck.Truck, name string) []seattlefoodtruck.Truck {
+	var matches []seattlefoodtruck.Truck
+	for _, i := range matchNames(name, len(trucks), func(i int) []string {
+		return []string{trucks[i].ID, trucks[i].Name}
+	}) {
+		matches = append(matches, trucks[i])
+	}
+	return matches
+}
+
+func (b *bot) showCacheStats() string {
+	cached, ok := b.source.(provider.CacheReporter)
+	if !ok {
+		return "I don't cache food truck schedules"
+	}
+	stats := cached.CacheStats()
+	message := fmt.Sprintf("%s \n", "*Seattle food trucks api cache*")
+	message += fmt.Sprintf("• Hits: %d \n", stats.Hits)
+	message += fmt.Sprintf("• Revalidated: %d \n", stats.Revalidations)
+	message += fmt.Sprintf("• Misses: %d \n", stats.Misses)
+	message += fmt.Sprintf("• Shared with an identical request: %d \n", stats.Coalesced)
+	message += fmt.Sprintf("• Hit ratio: %.0f%% \n", stats.HitRatio()*100)
+	return message
+}
+
+func (b *bot) getTrucksForLocation(ctx context.Context, location seattlefoodtruck.Location) string {
+	req := seattlefoodtruck.NewLocationEventsRequest(location.UID, 1)
+	allEvents, err := b.source.Events(ctx, req.Query().On(time.Now()))
+	partial := err == seattlefoodtruck.ErrPageLimit
+	if partial {
+		logger(ctx).Warn("Showing partial list of events", "error", err)
+	} else if err != nil {
+		return errorMessage(ctx, err)
+	}
+	p := locationPoint(location)
+	if !p.Valid() {
+		p = b.locationPoints(ctx)[location.UID]
+	}
+	message := b.trucksMessage(locationLabel(location), b.distanceNote(p), allEvents)
+	if partial {
+		message += "_Could not get every event at this location, there may be more trucks_ \n"
+	}
+	return message
+}
+
+//locationLabel names a location in messages, locations given by uid only are shown by uid
+func locationLabel(l seattlefoodtruck.Location) string {
+	if len(l.Name) == 0 {
+		return strconv.Itoa(l.UID)
+	}
+	return l.Name
+}
+
+//trucksMessage lists the trucks booked today among the events at a location, note is appended to
+//the location name
+func (b *bot) trucksMessage(locString string, note string, allEvents []seattlefoodtruck.Event) (message string) {
+	if len(allEvents) == 0 {
+		message = fmt.Sprintf("No events at %v", locString)
+		return
+	}
+	events := find(allEvents, filterByStartDate)
+	if events == nil {
+		message = fmt.Sprintf("No food trucks found at %v", locString)
+		return
+	}
+	message = ""
+	for _, eventIndex := range events {
+		event := allEvents[eventIndex]
+		if len(event.Bookings) != 0 {
+			message += b.eventMessage(event, event.Location.Name+note)
+		}
+	}
+	return message
+}
+
+//eventMessage lists the trucks booked at an event under a heading with title and the event times
+func (b *bot) eventMessage(event seattlefoodtruck.Event, title string) string {
+	st := event.LocalStart()
+	et := event.LocalEnd()
+	_, m, d := st.Date()
+	message := fmt.Sprintf("*%s* \t %v %v %v - %v \n", title, m, d, st.Format(time.Kitchen), et.Format(time.Kitchen))
+
+	for _, booking := range event.Bookings {
+		message += fmt.Sprintf("*%v* (%s) %v \n", booking.Truck.Name,
+			strings.Join(booking.Truck.FoodCategories, ", "), b.source.PhotoURL(booking.Truck.FeaturedPhoto))
+	}
+	return message
+}
+
+//errorMessage turns an error from the food truck api into something we can show in slack
+func errorMessage(ctx context.Context, err error) string {
+	args := []any{"error", err}
+	var apiErr *seattlefoodtruck.APIError
+	if errors.As(err, &apiErr) {
+		args = append(args, "endpoint", apiErr.Endpoint, "status", apiErr.StatusCode)
+	}
+	logger(ctx).Warn("Food truck api error", args...)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "Seattle food trucks is taking too long to answer, please try again later"
+	case errors.Is(err, context.Canceled):
+		return "I stopped waiting for seattle food trucks, please try again"
+	case errors.Is(err, seattlefoodtruck.ErrNotFound):
+		return "Seattle food trucks could not find what you asked for"
+	case errors.Is(err, seattlefoodtruck.ErrRateLimited):
+		return "Seattle food trucks asked us to slow down, please try again in a minute"
+	case errors.Is(err, seattlefoodtruck.ErrUnavailable):
+		return "Seattle food trucks is unavailable right now, please try again later"
+	case errors.Is(err, seattlefoodtruck.ErrDecode):
+		return "Seattle food trucks sent an answer I could not understand"
+	}
+	return err.Error()
+}
+
+//returns a slice of indeces of found events matching the passed function, if none returns nil
+func find(events []seattlefoodtruck.Event, f func(seattlefoodtruck.Event) bool) []int {
+	var foundEvents []int
+	for i, e := range events {
+		if f(e) {
+			foundEvents = append(foundEvents, i)
+		}
+	}
+	return foundEvents
+}
+
+// used to filter events happening today in seattle
+func filterByStartDate(event seattlefoodtruck.Event) bool {
+	return event.OccursOn(time.Now())
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//todayFixture returns a fixture with trucks at location 44 yesterday and today
+func todayFixture() seattlefoodtruck.Fixture {
+	now := time.Now().In(seattlefoodtruck.Timezone())
+	lunch := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, seattlefoodtruck.Timezone())
+	factoria := seattlefoodtruck.Location{Name: "T-Mobile Factoria", ID: "t-mobile-factoria", UID: 44, NeighborhoodID: 4,
+		Address: "3625 132nd Ave SE, Bellevue, WA 98006, USA", FilteredAddress: "3625 132nd Ave SE", Latitude: 47.5778, Longitude: -122.1707}
+	truck := func(name string, categories ...string) seattlefoodtruck.Booking {
+		return seattlefoodtruck.Booking{Status: "approved", Truck: seattlefoodtruck.FoodTruck{Name: name, FoodCategories: categories}}
+	}
+	return seattlefoodtruck.Fixture{
+		Neighborhoods: []seattlefoodtruck.Neighborhood{{Name: "Bellevue", ID: "bellevue", UID: 4}},
+		Locations:     []seattlefoodtruck.Location{factoria},
+		Trucks: []seattlefoodtruck.Truck{
+			{Name: "Marination", ID: "marination", FoodCategories: []string{"Hawaiian", "Korean"}, Description: "Kalua pork sliders", Website: "http://marinationmobile.com", Photos: []string{"trucks/marination.jpg"}},
+			{Name: "Taco Time", ID: "taco-time", FoodCategories: []string{"Tacos"}},
+			{Name: "Tacos El Tajin", ID: "tacos-el-tajin", FoodCategories: []string{"Tacos"}, Trailer: true},
+		},
+		Events: []seattlefoodtruck.Event{
+			{
+				ID:        1,
+				StartTime: lunch.AddDate(0, 0, -1),
+				EndTime:   lunch.AddDate(0, 0, -1).Add(3 * time.Hour),
+				Bookings:  []seattlefoodtruck.Booking{truck("Yesterday Tacos")},
+				Location:  factoria,
+			},
+			{
+				ID:        2,
+				StartTime: lunch,
+				EndTime:   lunch.Add(3 * time.Hour),
+				Bookings:  []seattlefoodtruck.Booking{truck("Marination", "Hawaiian", "Korean")},
+				Location:  factoria,
+			},
+		},
+	}
+}
+
+func TestRespondShowNeighborhoods(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture())))
+	got := b.respond(context.Background(), "show neighborhoods")
+	if !strings.Contains(got, "• bellevue") {
+		t.Errorf("Expected bellevue in '%v'", got)
+	}
+}
+
+func TestRespondShowLocations(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture())))
+	got := b.respond(context.Background(), "show locations in bellevue")
+	if !strings.Contains(got, "T-Mobile Factoria - 44") {
+		t.Errorf("Expected location 44 in '%v'", got)
+	}
+}
+
+func TestRespondShowTrucks(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture())))
+	got := b.respond(context.Background(), "show trucks at 44")
+	if !strings.Contains(got, "*Marination* (Hawaiian, Korean)") {
+		t.Errorf("Expected today's truck in '%v'", got)
+	}
+	if strings.Contains(got, "Yesterday Tacos") {
+		t.Errorf("Expected only today's trucks in '%v'", got)
+	}
+}
+
+func TestShowTrucksForLocationsReportsErrors(t *testing.T) {
+	f := seattlefoodtruck.NewFakeClient(todayFixture())
+	f.SetError(seattlefoodtruck.ErrUnavailable)
+	b := newBot(provider.NewSeattle(f))
+	got, err := b.showTrucksForLocations(context.Background(), []string{"44"})
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if !strings.Contains(got, "unavailable") {
+		t.Errorf("Expected unavailable message in '%v'", got)
+	}
+}
+
+func TestRespondUnknownCommand(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(seattlefoodtruck.Fixture{})))
+	got := b.respond(context.Background(), "make me a sandwich")
+	if !strings.HasPrefix(got, "Sorry I cannot help you") {
+		t.Errorf("Expected apology got '%v'", got)
+	}
+}
+
+func TestRespondTruck(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture())))
+	got := b.respond(context.Background(), "truck Marination")
+	for _, want := range []string{"*Marination* (Hawaiian, Korean)", "Kalua pork sliders", "Website: http://marinationmobile.com", provider.PhotoBucket + "trucks/marination.jpg"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected '%v' in '%v'", want, got)
+		}
+	}
+}
+
+func TestRespondTruckAmbiguous(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture())))
+	got := b.respond(context.Background(), "truck taco")
+	if !strings.Contains(got, "Taco Time - truck taco-time") || !strings.Contains(got, "Tacos El Tajin - truck tacos-el-tajin") {
+		t.Errorf("Expected both taco trucks in '%v'", got)
+	}
+	got = b.respond(context.Background(), "truck el tajin")
+	if !strings.Contains(got, "*Tacos El Tajin* (Tacos)") || !strings.Contains(got, "Trailer") {
+		t.Errorf("Expected Tacos El Tajin profile in '%v'", got)
+	}
+	got = b.respond(context.Background(), "truck pizza")
+	if !strings.Contains(got, "No food truck named pizza") {
+		t.Errorf("Expected no match in '%v'", got)
+	}
+}
+
+func TestRespondWhereIs(t *testing.T) {
+	f := todayFixture()
+	now := time.Now().In(seattlefoodtruck.Timezone())
+	lunch := time.Date(now.Year(), now.Month(), now.Day(), 11, 0, 0, 0, seattlefoodtruck.Timezone())
+	doppler := seattlefoodtruck.Location{Name: "Amazon Doppler", UID: 88, FilteredAddress: "2021 7th Ave"}
+	booking := seattlefoodtruck.Booking{Status: "approved", Truck: seattlefoodtruck.FoodTruck{Name: "Marination", ID: "marination"}}
+	f.Events = []seattlefoodtruck.Event{
+		{ID: 3, StartTime: lunch.AddDate(0, 0, 2), EndTime: lunch.AddDate(0, 0, 2).Add(3 * time.Hour), Bookings: []seattlefoodtruck.Booking{booking}, Location: doppler},
+		{ID: 4, StartTime: lunch.AddDate(0, 0, -2), EndTime: lunch.AddDate(0, 0, -2).Add(3 * time.Hour), Bookings: []seattlefoodtruck.Booking{booking}, Location: doppler},
+		{ID: 5, StartTime: lunch.AddDate(0, 0, 10), EndTime: lunch.AddDate(0, 0, 10).Add(3 * time.Hour), Bookings: []seattlefoodtruck.Booking{booking}, Location: doppler},
+	}
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(f)))
+
+	got := b.respond(context.Background(), "where is marinaton this week")
+	inTwoDays := lunch.AddDate(0, 0, 2)
+	want := fmt.Sprintf("• %v %v %v 11:00AM - 2:00PM at *Amazon Doppler* 2021 7th Ave", inTwoDays.Weekday(), inTwoDays.Month(), inTwoDays.Day())
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected '%v' in '%v'", want, got)
+	}
+	if strings.Count(got, "•") != 1 {
+		t.Errorf("Expected only this week's booking in '%v'", got)
+	}
+	got = b.respond(context.Background(), "where is tacos el tajin")
+	if !strings.Contains(got, "has no bookings this week") {
+		t.Errorf("Expected no bookings in '%v'", got)
+	}
+	got = b.respond(context.Background(), "where is taco")
+	if !strings.Contains(got, "Taco Time - where is taco-time") {
+		t.Errorf("Expected candidates in '%v'", got)
+	}
+}
+
+func TestShowTrucksForLocationsFetchesOnce(t *testing.T) {
+	f := todayFixture()
+	city := seattlefoodtruck.Location{Name: "Bellevue City Hall", UID: 52}
+	today := f.Events[1]
+	today.ID, today.Location = 3, city
+	f.Events = append(f.Events, today)
+	client := seattlefoodtruck.NewFakeClient(f)
+	b := newBot(provider.NewSeattle(client))
+
+	got, err := b.showTrucksForLocations(context.Background(), []string{"52", "44", "88"})
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	city52 := strings.Index(got, "*Bellevue City Hall*")
+	factoria := strings.Index(got, "*T-Mobile Factoria*")
+	if city52 < 0 || factoria < 0 || city52 > factoria {
+		t.Errorf("Expected locations in configured order in '%v'", got)
+	}
+	if !strings.Contains(got, "No events at 88") {
+		t.Errorf("Expected a line for location 88 in '%v'", got)
+	}
+	if client.Calls() != 1 {
+		t.Errorf("Expected one request for all locations got %d", client.Calls())
+	}
+}
+
+//namesFixture adds a second neighborhood and more locations to todayFixture for name resolution
+func namesFixture() seattlefoodtruck.Fixture {
+	f := todayFixture()
+	f.Neighborhoods = append(f.Neighborhoods, seattlefoodtruck.Neighborhood{Name: "South Lake Union", ID: "south-lake-union", UID: 11})
+	f.Locations = append(f.Locations,
+		seattlefoodtruck.Location{Name: "Bellevue City Hall", ID: "bellevue-city-hall", UID: 52, NeighborhoodID: 4, FilteredAddress: "450 110th Ave NE", Latitude: 47.6148, Longitude: -122.1929},
+		seattlefoodtruck.Location{Name: "Amazon Doppler", ID: "amazon-doppler", UID: 88, NeighborhoodID: 11, FilteredAddress: "2021 7th Ave", Latitude: 47.6159, Longitude: -122.3391},
+		seattlefoodtruck.Location{Name: "Amazon Day 1", ID: "amazon-day-1", UID: 89, NeighborhoodID: 11, FilteredAddress: "2121 7th Ave", Latitude: 47.6163, Longitude: -122.3380},
+	)
+	return f
+}
+
+func TestRespondResolvesNames(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(namesFixture())))
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"show trucks at t-mobile factoria", "*Marination* (Hawaiian, Korean)"},
+		{"show trucks at factoria", "*Marination* (Hawaiian, Korean)"},
+		{"show trucks at 3625 132nd ave", "*Marination* (Hawaiian, Korean)"},
+		{"show trucks at amazon", "Amazon Doppler (2021 7th Ave) - show trucks at 88"},
+		{"show trucks at amazon doppler", "No events at Amazon Doppler"},
+		{"show trucks at pike place", "No location named pike place"},
+		{"show trucks at 0", "0 is not a valid location"},
+		{"show trucks at abc", "No location named abc"},
+		{"show locations in south lake union", "Amazon Day 1 - 89"},
+		{"show locations in bellvue", "T-Mobile Factoria - 44"},
+		{"show locations in downtown", "No neighborhood named downtown"},
+	}
+	for _, c := range cases {
+		if got := b.respond(context.Background(), c.text); !strings.Contains(got, c.want) {
+			t.Errorf("%s: expected '%v' in '%v'", c.text, c.want, got)
+		}
+	}
+}
+
+func TestShowTrucksForLocationsByName(t *testing.T) {
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(namesFixture())))
+	got, err := b.showTrucksForLocations(context.Background(), []string{"t-mobile factoria", "amazon", "52"})
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	for _, want := range []string{"*Marination*", "Amazon Doppler (2021 7th Ave) - use 88", "No events at 52"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected '%v' in '%v'", want, got)
+		}
+	}
+}
+
+func TestRespondTrucksNear(t *testing.T) {
+	f := namesFixture()
+	today := f.Events[1]
+	today.ID, today.Location = 3, f.Locations[3] //Amazon Day 1
+	today.Bookings = []seattlefoodtruck.Booking{{Status: "approved", Truck: seattlefoodtruck.FoodTruck{Name: "Nosh"}}}
+	f.Events = append(f.Events, today)
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(f)))
+
+	got := b.respond(context.Background(), "trucks near amazon doppler within 500m")
+	if !strings.Contains(got, "*Amazon Day 1 (") || !strings.Contains(got, "*Nosh*") {
+		t.Errorf("Expected Nosh at Amazon Day 1 in '%v'", got)
+	}
+	if strings.Contains(got, "Marination") {
+		t.Errorf("Expected Factoria to be out of range in '%v'", got)
+	}
+	got = b.respond(context.Background(), "trucks near 47.6159,-122.3391 within 20 km")
+	day1, factoria := strings.Index(got, "*Amazon Day 1"), strings.Index(got, "*T-Mobile Factoria")
+	if day1 < 0 || factoria < 0 || day1 > factoria {
+		t.Errorf("Expected trucks sorted by distance in '%v'", got)
+	}
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"trucks near bellevue city hall", "No food trucks today within 1.0 km of Bellevue City Hall"},
+		{"trucks near 47.0,-121.0", "No food truck locations within 1.0 km"},
+		{"trucks near amazon doppler within far", "Invalid distance"},
+		{"trucks near 91,0", "Invalid coordinate"},
+		{"trucks near", "Missing location"},
+	}
+	for _, c := range cases {
+		if got := b.respond(context.Background(), c.text); !strings.Contains(got, c.want) {
+			t.Errorf("%s: expected '%v' in '%v'", c.text, c.want, got)
+		}
+	}
+}
+
+//pageLimitClient answers events requests with the first page only, as when the page cap is reached
+type pageLimitClient struct {
+	*seattlefoodtruck.FakeClient
+}
+
+func (c pageLimitClient) GetAllEventsContext(ctx context.Context, query seattlefoodtruck.EventsQuery) ([]seattlefoodtruck.Event, error) {
+	events, err := c.FakeClient.GetAllEventsContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return events, seattlefoodtruck.ErrPageLimit
+}
+
+func TestShowTrucksPartialList(t *testing.T) {
+	b := newBot(provider.NewSeattle(pageLimitClient{seattlefoodtruck.NewFakeClient(todayFixture())}))
+	got := b.respond(context.Background(), "show trucks at 44")
+	for _, want := range []string{"*Marination*", "_Could not get every event at this location, there may be more trucks_"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected '%v' in '%v'", want, got)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//changeKind says how a truck's booking changed between polls
+type changeKind int
+
+const (
+	truckAdded changeKind = iota
+	truckCancelled
+	hoursChanged
+)
+
+//scheduleSlot is a truck booked at a location today
+type scheduleSlot struct {
+	location string
+	truck    string
+	start    time.Time
+	end      time.Time
+}
+
+//scheduleChange is a difference between two polls of today's schedule
+type scheduleChange struct {
+	kind   changeKind
+	before scheduleSlot
+	after  scheduleSlot
+}
+
+//changePoller watches today's schedule at the digest locations and reports trucks that were added,
+//cancelled or changed their hours since the last poll. It is safe for concurrent use
+type changePoller struct {
+	bot       *bot
+	locations []string
+
+	mu sync.Mutex
+	//day is the date seen and thread belong to, both are forgotten when the day changes
+	day    string
+	seen   map[string]scheduleSlot
+	thread string
+}
+
+//newChangePoller creates a poller for locations given by uid or name
+func newChangePoller(b *bot, locations []string) *changePoller {
+	return &changePoller{bot: b, locations: locations}
+}
+
+//setThread remembers the timestamp of today's digest so alerts can be threaded under it
+func (p *changePoller) setThread(ts string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rollover(time.Now())
+	p.thread = ts
+}
+
+//threadTimestamp returns the timestamp of today's digest, empty when it was not posted
+func (p *changePoller) threadTimestamp() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rollover(time.Now())
+	return p.thread
+}
+
+//rollover forgets the previous day's state, p.mu must be held
+func (p *changePoller) rollover(now time.Time) {
+	day := now.In(seattlefoodtruck.Timezone()).Format("2006-01-02")
+	if day != p.day {
+		p.day, p.seen, p.thread = day, nil, ""
+	}
+}
+
+//poll fetches today's schedule and returns how it changed since the last poll. The first poll of
+//a day only records the schedule. Nothing is recorded when the schedule could not be fetched in
+//full, so a failed poll never reports trucks as cancelled
+func (p *changePoller) poll(ctx context.Context) ([]scheduleChange, error) {
+	now := time.Now()
+	current, err := p.bot.todaysSlots(ctx, p.locations, now)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rollover(now)
+	if p.seen == nil {
+		p.seen = current
+		return nil, nil
+	}
+	changes := diffSchedules(p.seen, current)
+	p.seen = current
+	return changes, nil
+}
+
+//todaysSlots returns the approved bookings at locations today keyed by location, event and truck so
+//a truck booked for lunch and dinner at one location has a slot for each
+func (b *bot) todaysSlots(ctx context.Context, locations []string, now time.Time) (map[string]scheduleSlot, error) {
+	var uids []int
+	for _, l := range locations {
+		location, problem := b.resolveLocation(ctx, l, "use")
+		if location == nil {
+			return nil, fmt.Errorf("Could not resolve location %q: %s", l, problem)
+		}
+		uids = append(uids, location.UID)
+	}
+	query := seattlefoodtruck.NewEventsQuery().ForLocations(uids...).On(now).
+		WithBookingStatus(seattlefoodtruck.BookingApproved)
+	events, err := b.source.Events(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	slots := make(map[string]scheduleSlot)
+	for _, e := range events {
+		for _, booking := range e.Bookings {
+			truck := booking.Truck.ID
+			if len(truck) == 0 {
+				truck = booking.Truck.Name
+			}
+			key := fmt.Sprintf("%d/%d/%s", e.Location.UID, e.ID, strings.ToLower(truck))
+			slots[key] = scheduleSlot{location: locationLabel(e.Location), truck: booking.Truck.Name,
+				start: e.LocalStart(), end: e.LocalEnd()}
+		}
+	}
+	return slots, nil
+}
+
+//diffSchedules lists the changes from before to after ordered by start time
+func diffSchedules(before, after map[string]scheduleSlot) []scheduleChange {
+	var changes []scheduleChange
+	for key, a := range after {
+		b, ok := before[key]
+		switch {
+		case !ok:
+			changes = append(changes, scheduleChange{kind: truckAdded, after: a})
+		case !b.start.Equal(a.start) || !b.end.Equal(a.end):
+			changes = append(changes, scheduleChange{kind: hoursChanged, before: b, after: a})
+		}
+	}
+	for key, b := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, scheduleChange{kind: truckCancelled, before: b})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		si, sj := changes[i].slot(), changes[j].slot()
+		if !si.start.Equal(sj.start) {
+			return si.start.Before(sj.start)
+		}
+		if si.location != sj.location {
+			return si.location < sj.location
+		}
+		return si.truck < sj.truck
+	})
+	return changes
+}
+
+//slot returns the booking a change is about, as it is now unless it was cancelled
+func (c scheduleChange) slot() scheduleSlot {
+	if c.kind == truckCancelled {
+		return c.before
+	}
+	return c.after
+}
+
+//hours formats a slot's times, e.g. 11:00AM - 2:00PM
+func (s scheduleSlot) hours() string {
+	return fmt.Sprintf("%s - %s", s.start.Format(time.Kitchen), s.end.Format(time.Kitchen))
+}
+
+//changesMessage formats schedule changes as a slack message
+func changesMessage(changes []scheduleChange) string {
+	message := fmt.Sprintf("%s \n", "*Food truck schedule changes*")
+	for _, c := range changes {
+		switch c.kind {
+		case truckAdded:
+			message += fmt.Sprintf(":new: *%s* added at *%s* %s \n", c.after.truck, c.after.location, c.after.hours())
+		case truckCancelled:
+			message += fmt.Sprintf(":x: *%s* cancelled at *%s* (was %s) \n", c.before.truck, c.before.location, c.before.hours())
+		case hoursChanged:
+			message += fmt.Sprintf(":clock3: *%s* at *%s* now %s (was %s) \n", c.after.truck, c.after.location, c.after.hours(), c.before.hours())
+		}
+	}
+	return message
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestDiffSchedules(t *testing.T) {
+	lunch := time.Date(2018, 5, 1, 11, 0, 0, 0, time.UTC)
+	slot := func(truck string, start time.Time) scheduleSlot {
+		return scheduleSlot{location: "Amazon Doppler", truck: truck, start: start, end: start.Add(3 * time.Hour)}
+	}
+	before := map[string]scheduleSlot{
+		"88/marination":  slot("Marination", lunch),
+		"88/nosh":        slot("Nosh", lunch),
+		"88/off-the-rez": slot("Off the Rez", lunch),
+	}
+	after := map[string]scheduleSlot{
+		"88/marination":  slot("Marination", lunch),
+		"88/nosh":        slot("Nosh", lunch.Add(time.Hour)),
+		"88/where-ya-at": slot("Where Ya At Matt", lunch.Add(-time.Hour)),
+	}
+	changes := diffSchedules(before, after)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes got %+v", changes)
+	}
+	want := []struct {
+		kind  changeKind
+		truck string
+	}{{truckAdded, "Where Ya At Matt"}, {truckCancelled, "Off the Rez"}, {hoursChanged, "Nosh"}}
+	for i, w := range want {
+		if changes[i].kind != w.kind || changes[i].slot().truck != w.truck {
+			t.Errorf("Expected change %d to be %v for %s got %+v", i, w.kind, w.truck, changes[i])
+		}
+	}
+	message := changesMessage(changes)
+	for _, line := range []string{
+		":new: *Where Ya At Matt* added at *Amazon Doppler* 10:00AM - 1:00PM",
+		":x: *Off the Rez* cancelled at *Amazon Doppler* (was 11:00AM - 2:00PM)",
+		":clock3: *Nosh* at *Amazon Doppler* now 12:00PM - 3:00PM (was 11:00AM - 2:00PM)",
+	} {
+		if !strings.Contains(message, line) {
+			t.Errorf("Expected '%v' in '%v'", line, message)
+		}
+	}
+}
+
+func TestChangePoller(t *testing.T) {
+	f := todayFixture()
+	client := seattlefoodtruck.NewFakeClient(f)
+	p := newChangePoller(newBot(provider.NewSeattle(client)), []string{"44"})
+	ctx := context.Background()
+
+	if changes, err := p.poll(ctx); err != nil || len(changes) != 0 {
+		t.Fatalf("Expected the first poll to only record the schedule got %v, %v", changes, err)
+	}
+	today := &f.Events[1]
+	today.Bookings = append(today.Bookings, seattlefoodtruck.Booking{Status: "approved", Truck: seattlefoodtruck.FoodTruck{Name: "Nosh", ID: "nosh"}})
+	today.Bookings[0].Status = "cancelled"
+	client.SetFixture(f)
+
+	changes, err := p.poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(changes) != 2 || changes[0].kind != truckCancelled || changes[1].kind != truckAdded || changes[1].after.truck != "Nosh" {
+		t.Errorf("Expected Nosh added and Marination cancelled got %+v", changes)
+	}
+
+	//a failed poll reports nothing and keeps the last schedule
+	client.SetError(seattlefoodtruck.ErrUnavailable)
+	if _, err := p.poll(ctx); err == nil {
+		t.Error("Expected an error while the api is down")
+	}
+	client.SetError(nil)
+	if changes, err := p.poll(ctx); err != nil || len(changes) != 0 {
+		t.Errorf("Expected no changes after the api recovered got %v, %v", changes, err)
+	}
+}
+
+func TestChangePollerLunchAndDinner(t *testing.T) {
+	f := todayFixture()
+	lunch := f.Events[1]
+	dinner := lunch
+	dinner.ID = 3
+	dinner.StartTime, dinner.EndTime = lunch.StartTime.Add(6*time.Hour), lunch.EndTime.Add(6*time.Hour)
+	dinner.Bookings = []seattlefoodtruck.Booking{lunch.Bookings[0]}
+	f.Events = append(f.Events, dinner)
+	client := seattlefoodtruck.NewFakeClient(f)
+	p := newChangePoller(newBot(provider.NewSeattle(client)), []string{"44"})
+	ctx := context.Background()
+
+	if _, err := p.poll(ctx); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	f.Events[2].Bookings[0].Status = "cancelled"
+	client.SetFixture(f)
+	changes, err := p.poll(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(changes) != 1 || changes[0].kind != truckCancelled || !changes[0].before.start.Equal(dinner.StartTime) {
+		t.Errorf("Expected only the dinner booking cancelled got %+v", changes)
+	}
+}
+
+func TestChangePollerThread(t *testing.T) {
+	p := newChangePoller(nil, nil)
+	p.setThread("1525190400.000100")
+	if got := p.threadTimestamp(); got != "1525190400.000100" {
+		t.Errorf("Expected today's digest thread got '%v'", got)
+	}
+	p.day = "2018-05-01"
+	if got := p.threadTimestamp(); got != "" {
+		t.Errorf("Expected yesterday's thread to be forgotten got '%v'", got)
+	}
+}
@@ -0,0 +1,49 @@
+//Command foodtruck-emulator serves a local stand-in for the seattlefoodtruck.com api.
+//
+//	foodtruck-emulator -addr :8089 -fixture fixture.json
+//
+//Point the bot or the proxy tests at it with SEATTLEFOODTRUCK_API=http://localhost:8089
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck/emulator"
+)
+
+func main() {
+	addr := flag.String("addr", ":8089", "address to listen on")
+	fixture := flag.String("fixture", "", "json fixture to serve, the bundled dataset when empty")
+	pageSize := flag.Int("page-size", emulator.DefaultPageSize, "items per page")
+	today := flag.Bool("today", true, "move events so the first one happens today")
+	flag.Parse()
+
+	f := emulator.DefaultFixture()
+	if *fixture != "" {
+		var err error
+		f, err = seattlefoodtruck.LoadFixture(*fixture)
+		if err != nil {
+			log.Fatalln("Failed to load fixture: ", err)
+		}
+	}
+	if *today {
+		f = f.ShiftedTo(time.Now())
+	}
+	s := emulator.NewServer(f)
+	s.PageSize = *pageSize
+
+	log.Printf("Serving %d neighborhoods, %d locations and %d events on %s \n",
+		len(f.Neighborhoods), len(f.Locations), len(f.Events), *addr)
+	log.Fatal(http.ListenAndServe(*addr, logRequests(s)))
+}
+
+func logRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.Println(r.Method, r.URL.RequestURI())
+		h.ServeHTTP(w, r)
+	})
+}
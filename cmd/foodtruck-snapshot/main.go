@@ -0,0 +1,58 @@
+//Command foodtruck-snapshot crawls seattlefoodtruck.com into a snapshot file the bot can answer from.
+//
+//	foodtruck-snapshot -dir snapshots -days 7 -keep 14
+//
+//Run the bot with SNAPSHOT_DIR=snapshots to answer every command from the latest snapshot
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+	"github.com/rprakashg/foodtruck-slack-bot/snapshot"
+)
+
+func main() {
+	api := flag.String("api", "https://www.seattlefoodtruck.com", "address of the api to crawl")
+	dir := flag.String("dir", "snapshots", "directory to write the snapshot to")
+	days := flag.Int("days", 7, "days of upcoming events to crawl, starting today")
+	keep := flag.Int("keep", 0, "snapshots to keep in dir, all when 0")
+	timeout := flag.Duration("timeout", 10*time.Minute, "time allowed for the whole crawl")
+	flag.Parse()
+
+	p, err := seattlefoodtruck.NewProxy(*api)
+	if err != nil {
+		log.Fatalln("Failed to create food truck client: ", err)
+	}
+	p.Retry.OnRetry = func(e seattlefoodtruck.RetryEvent) {
+		log.Printf("Retrying %s after attempt %d failed, waiting %v: %v \n", e.Endpoint, e.Attempt, e.Delay, e.Err)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	start := time.Now().In(seattlefoodtruck.Timezone())
+	s, err := snapshot.Crawl(ctx, p, *api, start, start.AddDate(0, 0, *days-1))
+	if err != nil {
+		log.Fatalln("Failed to crawl: ", err)
+	}
+	for _, what := range s.Incomplete {
+		log.Printf("Snapshot has a partial list of %s, the page limit was reached \n", what)
+	}
+	path, err := s.Write(*dir)
+	if err != nil {
+		log.Fatalln("Failed to write snapshot: ", err)
+	}
+	log.Printf("Wrote %d neighborhoods, %d locations, %d trucks and %d events to %s \n",
+		len(s.Neighborhoods), len(s.Locations), len(s.Trucks), len(s.Events), path)
+	if err := snapshot.Prune(*dir, *keep); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/robfig/cron"
+	"gopkg.in/yaml.v2"
+)
+
+//defaultSchedule is when a digest is posted unless it sets its own schedule, 8am on weekdays
+const defaultSchedule = "0 0 08 * * mon-fri"
+
+//config is what CONFIG_FILE sets up
+type config struct {
+	//SlackToken is overridden by SLACK_TOKEN so the file need not hold the secret
+	SlackToken string         `yaml:"slack_token"`
+	Digests    []digestConfig `yaml:"digests"`
+}
+
+//digestConfig is a digest of the trucks at some locations posted to a channel on a schedule
+type digestConfig struct {
+	//Name tells digests apart in logs, the channel by default
+	Name      string   `yaml:"name"`
+	Channel   string   `yaml:"channel"`
+	Locations []string `yaml:"locations"`
+	//Schedule is a cron expression with seconds, defaultSchedule when empty
+	Schedule string `yaml:"schedule"`
+	//Timezone is the zone Schedule is in, the bot's local time when empty
+	Timezone string `yaml:"timezone"`
+	//Title is shown above the digest
+	Title string `yaml:"title"`
+	//SortByDistance overrides SORT_BY_DISTANCE for this digest
+	SortByDistance *bool `yaml:"sort_by_distance"`
+	//ChangeAlerts posts added and cancelled trucks under the digest, on unless false
+	ChangeAlerts *bool `yaml:"change_alerts"`
+
+	schedule cron.Schedule
+}
+
+//loadConfig reads the config file at path, or builds the config from LOCATION_IDS and CHANNEL when
+//path is empty. SLACK_TOKEN overrides the token in the file
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if path == "" {
+		if len(locations) > 0 && channel != "" {
+			cfg.Digests = []digestConfig{{Channel: channel, Locations: locations}}
+		}
+	} else {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err = parseConfig(data); err != nil {
+			return nil, fmt.Errorf("Invalid config file %s: %v", path, err)
+		}
+	}
+	if token != "" {
+		cfg.SlackToken = token
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+//parseConfig parses a yaml config, fields it does not know are errors to catch typos
+func parseConfig(data []byte) (*config, error) {
+	var cfg config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+//validate checks every digest, filling in defaults and parsing schedules, and reports all the
+//problems found
+func (c *config) validate() error {
+	var errs []error
+	if c.SlackToken == "" {
+		errs = append(errs, fmt.Errorf("No slack token, set SLACK_TOKEN or slack_token"))
+	}
+	names := make(map[string]bool)
+	for i := range c.Digests {
+		d := &c.Digests[i]
+		if d.Name == "" {
+			d.Name = d.Channel
+		}
+		label := fmt.Sprintf("Digest %d", i+1)
+		if d.Name != "" {
+			label += fmt.Sprintf(" (%s)", d.Name)
+		}
+		if d.Channel == "" {
+			errs = append(errs, fmt.Errorf("%s: no channel", label))
+		}
+		if len(d.Locations) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no locations", label))
+		}
+		if names[d.Name] {
+			errs = append(errs, fmt.Errorf("%s: name is used by another digest, set a different name", label))
+		}
+		names[d.Name] = true
+		if d.Schedule == "" {
+			d.Schedule = defaultSchedule
+		}
+		schedule, err := cron.Parse(d.Schedule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid schedule %q: %v", label, d.Schedule, err))
+			continue
+		}
+		if d.Timezone != "" {
+			loc, err := time.LoadLocation(d.Timezone)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid timezone %q", label, d.Timezone))
+				continue
+			}
+			schedule = inZone{schedule, loc}
+		}
+		d.schedule = schedule
+	}
+	return errors.Join(errs...)
+}
+
+//changeAlerts says whether added and cancelled trucks are posted under the digest
+func (d digestConfig) changeAlerts() bool {
+	return d.ChangeAlerts == nil || *d.ChangeAlerts
+}
+
+//inZone runs a cron schedule in loc rather than the zone of the cron
+type inZone struct {
+	cron.Schedule
+	loc *time.Location
+}
+
+//Next returns the next time the schedule runs after t
+func (s inZone) Next(t time.Time) time.Time {
+	return s.Schedule.Next(t.In(s.loc))
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+slack_token: from-file
+digests:
+  - name: downtown
+    channel: C1
+    locations: [44, amazon doppler]
+    schedule: "0 30 10 * * mon-fri"
+    timezone: America/New_York
+    title: Lunch downtown
+    sort_by_distance: true
+  - channel: C2
+    locations: [occidental park]
+    change_alerts: false
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(saved string) { token = saved }(token)
+
+	token = ""
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if cfg.SlackToken != "from-file" || len(cfg.Digests) != 2 {
+		t.Fatalf("Unexpected config %+v", cfg)
+	}
+	d := cfg.Digests[0]
+	if d.Locations[0] != "44" || d.Locations[1] != "amazon doppler" || !*d.SortByDistance || !d.changeAlerts() {
+		t.Errorf("Unexpected digest %+v", d)
+	}
+	newYork, _ := time.LoadLocation("America/New_York")
+	monday := time.Date(2018, 5, 7, 0, 0, 0, 0, time.UTC)
+	if next := d.schedule.Next(monday); !next.Equal(time.Date(2018, 5, 7, 10, 30, 0, 0, newYork)) {
+		t.Errorf("Expected 10:30 in New York got %v", next)
+	}
+	d = cfg.Digests[1]
+	if d.Name != "C2" || d.Schedule != defaultSchedule || d.changeAlerts() {
+		t.Errorf("Expected defaults filled in got %+v", d)
+	}
+
+	token = "from-env"
+	if cfg, err = loadConfig(path); err != nil || cfg.SlackToken != "from-env" {
+		t.Errorf("Expected SLACK_TOKEN to override the file got %v, %v", cfg, err)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	defer func(l []string, c string, s string) { locations, channel, token = l, c, s }(locations, channel, token)
+	locations, channel, token = []string{"44"}, "C1", "secret"
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(cfg.Digests) != 1 || cfg.Digests[0].Channel != "C1" || cfg.Digests[0].Schedule != defaultSchedule {
+		t.Errorf("Expected one digest from LOCATION_IDS and CHANNEL got %+v", cfg.Digests)
+	}
+
+	channel = ""
+	if cfg, err = loadConfig(""); err != nil || len(cfg.Digests) != 0 {
+		t.Errorf("Expected no digest without CHANNEL got %+v, %v", cfg, err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg, err := parseConfig([]byte(`
+digests:
+  - name: lunch
+    locations: [44]
+    schedule: every day at noon
+  - name: lunch
+    channel: C2
+    timezone: Mars/Olympus_Mons
+`))
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	err = cfg.validate()
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, want := range []string{
+		"No slack token",
+		"Digest 1 (lunch): no channel",
+		`Digest 1 (lunch): invalid schedule "every day at noon"`,
+		"Digest 2 (lunch): no locations",
+		"Digest 2 (lunch): name is used by another digest",
+		`Digest 2 (lunch): invalid timezone "Mars/Olympus_Mons"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected '%v' in '%v'", want, err)
+		}
+	}
+
+	if _, err := parseConfig([]byte("digests:\n  - chanel: C1\n")); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/geo"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+const (
+	//digestWorkers bounds how many api calls building the digest makes at once
+	digestWorkers = 4
+	//digestBatchSize is how many locations share one events request
+	digestBatchSize = 10
+)
+
+//forEach calls fn for 0 <= i < n on at most workers goroutines and returns the error of each call by
+//index. Once ctx is done no more calls are started and the remaining indexes get ctx's error
+func forEach(ctx context.Context, n int, workers int, fn func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	if workers < 1 {
+		workers = 1
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = fn(ctx, i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	close(next)
+	wg.Wait()
+	return errs
+}
+
+//digestEntry is a LOCATION_IDS entry and what became of it
+type digestEntry struct {
+	input    string
+	location *seattlefoodtruck.Location
+	//problem explains why the entry could not be resolved to a location
+	problem string
+	events  []seattlefoodtruck.Event
+	//err is set when the location's events could not be fetched
+	err error
+}
+
+//showTrucksForLocations builds the daily digest for locations given by uid or name. Entries are
+//resolved and today's events fetched in batches on a bounded pool of workers, locations that fail
+//are marked in the digest while the rest are still listed in the order they are given
+func (b *bot) showTrucksForLocations(ctx context.Context, locations []string) (string, error) {
+	if len(locations) == 0 {
+		logger(ctx).Warn("No locations set")
+		return "", fmt.Errorf("No locations to show trucks for")
+	}
+	ctx, cancel := context.WithTimeout(ctx, digestDeadline)
+	defer cancel()
+
+	entries := make([]digestEntry, len(locations))
+	errs := forEach(ctx, len(locations), digestWorkers, func(ctx context.Context, i int) error {
+		entries[i].location, entries[i].problem = b.resolveLocation(ctx, locations[i], "use")
+		return nil
+	})
+	var resolved []*digestEntry
+	for i := range entries {
+		e := &entries[i]
+		e.input = locations[i]
+		if errs[i] != nil {
+			e.problem = errorMessage(ctx, errs[i])
+		}
+		if e.location == nil {
+			logger(ctx).Warn("Skipping location", "location", e.input, "problem", e.problem)
+			continue
+		}
+		resolved = append(resolved, e)
+	}
+	if len(resolved) == 0 {
+		return "", fmt.Errorf("No valid locations to show trucks for")
+	}
+
+	var batches [][]*digestEntry
+	for len(resolved) > 0 {
+		n := min(digestBatchSize, len(resolved))
+		batches = append(batches, resolved[:n])
+		resolved = resolved[n:]
+	}
+	errs = forEach(ctx, len(batches), digestWorkers, func(ctx context.Context, i int) error {
+		return b.fetchDigestBatch(ctx, batches[i])
+	})
+	for i, batch := range batches {
+		for _, e := range batch {
+			e.err = errs[i]
+		}
+	}
+	return b.digestMessage(ctx, entries), nil
+}
+
+//fetchDigestBatch gets today's events at the locations of a batch in one request
+func (b *bot) fetchDigestBatch(ctx context.Context, batch []*digestEntry) error {
+	uids := make([]int, len(batch))
+	for i, e := range batch {
+		uids[i] = e.location.UID
+	}
+	logger(ctx).Debug("Getting trucks for locations", "locations", uids)
+	query := seattlefoodtruck.NewEventsQuery().ForLocations(uids...).On(time.Now()).
+		WithActiveTrucks(true).WithBookingStatus(seattlefoodtruck.BookingApproved)
+	allEvents, err := b.source.Events(ctx, query)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Showing partial list of events", "error", err)
+	} else if err != nil {
+		return err
+	}
+	for _, event := range allEvents {
+		for _, e := range batch {
+			if e.location.UID == event.Location.UID {
+				e.events = append(e.events, event)
+			}
+		}
+	}
+	return nil
+}
+
+//digestMessage lists the trucks at each entry, marking entries that could not be resolved or
+//fetched, after a warning counting the failed locations
+func (b *bot) digestMessage(ctx context.Context, entries []digestEntry) string {
+	var message string
+	failed := 0
+	for _, e := range entries {
+		if e.location == nil || e.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		message = fmt.Sprintf("_Could not get trucks for %d of %d locations, showing the rest_ \n", failed, len(entries))
+	}
+	points := b.locationPoints(ctx)
+	ordered := make([]digestEntry, len(entries))
+	copy(ordered, entries)
+	if b.sortByDistance {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return b.officeDistance(entryPoint(ordered[i], points)) < b.officeDistance(entryPoint(ordered[j], points))
+		})
+	}
+	for _, e := range ordered {
+		switch {
+		case e.location == nil:
+			message += fmt.Sprintf("%s \n", e.problem)
+		case e.err != nil:
+			message += fmt.Sprintf(":warning: *%s* - %s \n", locationLabel(*e.location), errorMessage(ctx, e.err))
+		default:
+			note := b.distanceNote(entryPoint(e, points))
+			message += fmt.Sprintf("%s \n", b.trucksMessage(locationLabel(*e.location), note, e.events))
+		}
+	}
+	return message
+}
+
+//entryPoint returns the coordinates of an entry's location, invalid when unknown
+func entryPoint(e digestEntry, points map[int]geo.Point) geo.Point {
+	if e.location == nil {
+		return geo.Point{}
+	}
+	if p := locationPoint(*e.location); p.Valid() {
+		return p
+	}
+	return points[e.location.UID]
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestForEachBoundsWorkers(t *testing.T) {
+	var mu sync.Mutex
+	running, most := 0, 0
+	errs := forEach(context.Background(), 20, 3, func(ctx context.Context, i int) error {
+		mu.Lock()
+		running++
+		most = max(most, running)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if i%2 == 1 {
+			return fmt.Errorf("odd %d", i)
+		}
+		return nil
+	})
+	if most > 3 {
+		t.Errorf("Expected at most 3 calls at once got %d", most)
+	}
+	for i, err := range errs {
+		if (i%2 == 1) != (err != nil) {
+			t.Errorf("Expected error of call %d in its place got '%v'", i, err)
+		}
+	}
+}
+
+func TestForEachStopsWhenDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := forEach(ctx, 10, 1, func(ctx context.Context, i int) error {
+		if i == 2 {
+			cancel()
+		}
+		return nil
+	})
+	for i := 4; i < len(errs); i++ {
+		if errs[i] != context.Canceled {
+			t.Errorf("Expected call %d not to start got '%v'", i, errs[i])
+		}
+	}
+}
+
+//failingClient fails events requests that include a location
+type failingClient struct {
+	*seattlefoodtruck.FakeClient
+	uid int
+}
+
+func (c failingClient) GetAllEventsContext(ctx context.Context, query seattlefoodtruck.EventsQuery) ([]seattlefoodtruck.Event, error) {
+	for _, uid := range query.Locations() {
+		if uid == c.uid {
+			return nil, seattlefoodtruck.ErrUnavailable
+		}
+	}
+	return c.FakeClient.GetAllEventsContext(ctx, query)
+}
+
+func TestShowTrucksForLocationsPartialFailure(t *testing.T) {
+	//more locations than fit in a batch so the failing one only takes its own batch down
+	locations := make([]string, digestBatchSize+1)
+	for i := range locations {
+		locations[i] = fmt.Sprint(100 + i)
+	}
+	locations[digestBatchSize] = "44"
+	b := newBot(provider.NewSeattle(failingClient{FakeClient: seattlefoodtruck.NewFakeClient(todayFixture()), uid: 100}))
+
+	got, err := b.showTrucksForLocations(context.Background(), locations)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("_Could not get trucks for %d of %d locations", digestBatchSize, len(locations)),
+		":warning: *100* - ",
+		"*Marination*",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected '%v' in '%v'", want, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+//normalizeName lowercases name and drops everything but letters and digits, so "Where Ya At Matt"
+//and "where-ya-at-matt" compare equal
+func normalizeName(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+//editDistance returns the number of single character edits needed to turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+//maxTypos is how many edits a query of n characters may be away from a name and still match it
+func maxTypos(n int) int {
+	switch {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+//matchNames returns the indexes of the n candidates whose names match query. names returns the
+//names a candidate is known by, e.g. its id and display name. A candidate with an exact match wins
+//outright, otherwise every candidate containing query is returned, and when none does the ones
+//closest to query within a few typos
+func matchNames(query string, n int, names func(i int) []string) []int {
+	q := normalizeName(query)
+	if len(q) == 0 {
+		return nil
+	}
+	var partial, near []int
+	limit := maxTypos(len(q))
+	best := limit + 1
+	for i := 0; i < n; i++ {
+		contains := false
+		nearest := limit + 1
+		for _, name := range names(i) {
+			nn := normalizeName(name)
+			if nn == q {
+				return []int{i}
+			}
+			if strings.Contains(nn, q) {
+				contains = true
+			}
+			if d := editDistance(q, nn); d < nearest {
+				nearest = d
+			}
+		}
+		if contains {
+			partial = append(partial, i)
+		}
+		if nearest < best {
+			best, near = nearest, []int{i}
+		} else if nearest == best && nearest <= limit {
+			near = append(near, i)
+		}
+	}
+	if len(partial) > 0 {
+		return partial
+	}
+	return near
+}
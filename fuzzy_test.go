@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"nosh", "nosh", 0},
+		{"marinaton", "marination", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("Expected distance(%q, %q) = %d got %d", c.a, c.b, c.want, got)
+		}
+	}
+}
+
+func TestMatchNames(t *testing.T) {
+	names := [][]string{
+		{"marination", "Marination"},
+		{"where-ya-at-matt", "Where Ya At Matt"},
+		{"taco-time", "Taco Time"},
+		{"tacos-el-tajin", "Tacos El Tajin"},
+	}
+	lookup := func(i int) []string { return names[i] }
+	cases := []struct {
+		query string
+		want  []int
+	}{
+		{"Marination", []int{0}},
+		{"where ya at matt", []int{1}},
+		{"taco", []int{2, 3}},
+		{"taco time", []int{2}},
+		{"marinaton", []int{0}},
+		{"pizza", nil},
+		{"  ", nil},
+	}
+	for _, c := range cases {
+		if got := matchNames(c.query, len(names), lookup); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Expected matchNames(%q) = %v got %v", c.query, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+//Package geo computes distances between coordinates, good enough to tell which food trucks are a
+//short walk away
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//EarthRadius is the mean radius of the earth in meters
+const EarthRadius = 6371008.8
+
+//Point is a coordinate in decimal degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+//Valid reports whether the point is a coordinate on earth. The zero point is treated as missing,
+//the api sends it for locations without coordinates
+func (p Point) Valid() bool {
+	if p.Latitude == 0 && p.Longitude == 0 {
+		return false
+	}
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+//String formats the point as latitude,longitude
+func (p Point) String() string {
+	return strconv.FormatFloat(p.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(p.Longitude, 'f', -1, 64)
+}
+
+//ParsePoint reads a coordinate written as latitude,longitude, e.g. 47.6154,-122.3376
+func ParsePoint(s string) (Point, error) {
+	var p Point
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return p, fmt.Errorf("Invalid coordinate %q, expected latitude,longitude", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return p, fmt.Errorf("Invalid latitude %q", parts[0])
+	}
+	long, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return p, fmt.Errorf("Invalid longitude %q", parts[1])
+	}
+	p = Point{Latitude: lat, Longitude: long}
+	if !p.Valid() {
+		return p, fmt.Errorf("Invalid coordinate %q", s)
+	}
+	return p, nil
+}
+
+//Distance is a length in meters
+type Distance float64
+
+//Common distances
+const (
+	Meter     Distance = 1
+	Kilometer Distance = 1000
+	Foot      Distance = 0.3048
+	Mile      Distance = 1609.344
+)
+
+//Kilometers returns the distance in kilometers
+func (d Distance) Kilometers() float64 {
+	return float64(d / Kilometer)
+}
+
+//String formats the distance in meters below a kilometer and in kilometers above
+func (d Distance) String() string {
+	if d < Kilometer {
+		return fmt.Sprintf("%.0f m", float64(d))
+	}
+	return fmt.Sprintf("%.1f km", d.Kilometers())
+}
+
+var units = map[string]Distance{
+	"":           Meter,
+	"m":          Meter,
+	"meter":      Meter,
+	"meters":     Meter,
+	"km":         Kilometer,
+	"kilometer":  Kilometer,
+	"kilometers": Kilometer,
+	"ft":         Foot,
+	"feet":       Foot,
+	"mi":         Mile,
+	"mile":       Mile,
+	"miles":      Mile,
+}
+
+//ParseDistance reads a distance such as 500m, 1.5 km or 0.5 miles. Numbers without a unit are meters
+func ParseDistance(s string) (Distance, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("Invalid distance %q", s)
+	}
+	unit, ok := units[strings.TrimSpace(s[i:])]
+	if !ok {
+		return 0, fmt.Errorf("Invalid distance %q, unknown unit %q", s, strings.TrimSpace(s[i:]))
+	}
+	return Distance(n) * unit, nil
+}
+
+//Between returns the great circle distance between a and b using the haversine formula
+func Between(a, b Point) Distance {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLong := radians(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return Distance(2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h))))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	doppler := Point{Latitude: 47.6154, Longitude: -122.3376}
+	spaceNeedle := Point{Latitude: 47.6205, Longitude: -122.3493}
+	factoria := Point{Latitude: 47.5781, Longitude: -122.1655}
+	cases := []struct {
+		name string
+		a, b Point
+		want Distance
+	}{
+		{"same point", doppler, doppler, 0},
+		{"doppler to space needle", doppler, spaceNeedle, 1044},
+		{"doppler to factoria", doppler, factoria, 13555},
+		{"quarter of the equator", Point{0, 0}, Point{0, 90}, Distance(math.Pi / 2 * EarthRadius)},
+	}
+	for _, c := range cases {
+		got := Between(c.a, c.b)
+		if math.Abs(float64(got-c.want)) > 0.001*float64(c.want)+1 {
+			t.Errorf("%s: expected about %v got %v", c.name, c.want, got)
+		}
+		if back := Between(c.b, c.a); math.Abs(float64(back-got)) > 1e-6 {
+			t.Errorf("%s: expected a symmetric distance got %v and %v", c.name, got, back)
+		}
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	p, err := ParsePoint(" 47.6154, -122.3376 ")
+	if err != nil || p != (Point{Latitude: 47.6154, Longitude: -122.3376}) {
+		t.Errorf("Expected 47.6154,-122.3376 got %v, %v", p, err)
+	}
+	for _, s := range []string{"47.6", "north,south", "91,0", "0,0"} {
+		if _, err := ParsePoint(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestParseDistance(t *testing.T) {
+	cases := []struct {
+		s    string
+		want Distance
+	}{
+		{"500", 500},
+		{"500m", 500},
+		{"1.5 km", 1500},
+		{"1 mile", Mile},
+		{"0.5mi", Mile / 2},
+		{"1000 feet", 304.8},
+	}
+	for _, c := range cases {
+		got, err := ParseDistance(c.s)
+		if err != nil || math.Abs(float64(got-c.want)) > 1e-9 {
+			t.Errorf("Expected %q to be %v got %v, %v", c.s, c.want, got, err)
+		}
+	}
+	for _, s := range []string{"", "far", "-1 km", "2 leagues"} {
+		if _, err := ParseDistance(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestDistanceString(t *testing.T) {
+	if got := Distance(349.6).String(); got != "350 m" {
+		t.Errorf("Expected 350 m got %v", got)
+	}
+	if got := (1234 * Meter).String(); got != "1.2 km" {
+		t.Errorf("Expected 1.2 km got %v", got)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//apiReadyWindow is how long after its last success the api may keep failing before the bot reports
+//itself not ready
+const apiReadyWindow = 10 * time.Minute
+
+//health tracks what the bot needs to do its job for /healthz and /readyz. It is safe for
+//concurrent use
+type health struct {
+	mu      sync.Mutex
+	started time.Time
+	//connected is whether the slack rtm connection is up, authFailed whether slack refused the token
+	connected  bool
+	authFailed bool
+	//usesAPI is false when answering from a file, the api check then always passes
+	usesAPI     bool
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+	//cronEnabled is whether the digest is configured, cronEntries how many jobs are scheduled
+	cronEnabled bool
+	cronEntries func() int
+	now         func() time.Time
+}
+
+//newHealth starts tracking health from now
+func newHealth() *health {
+	return &health{started: time.Now(), now: time.Now}
+}
+
+//check is the result of one readiness check
+type check struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+//watchAPI makes readiness depend on requests to the api succeeding
+func (h *health) watchAPI() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.usesAPI = true
+}
+
+//setConnected records the state of the slack rtm connection
+func (h *health) setConnected(connected bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connected = connected
+}
+
+//setAuthFailed records that slack refused the token
+func (h *health) setAuthFailed() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authFailed, h.connected = true, false
+}
+
+//setCron records that the digest is scheduled, entries counts the scheduled jobs and is nil when no
+//digest is configured
+func (h *health) setCron(entries func() int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cronEnabled, h.cronEntries = entries != nil, entries
+}
+
+//observeRequest records the outcome of a request to the api made for a call with context ctx. Only
+//failures of the api itself count, not client errors such as a truck that was not found or calls
+//the caller gave up on
+func (h *health) observeRequest(ctx context.Context, e seattlefoodtruck.RequestEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case e.Err == nil:
+		h.lastSuccess = h.now()
+	case ctx.Err() != nil:
+		//the caller was cancelled or ran out of time, that says nothing about the api
+	case errors.Is(e.Err, seattlefoodtruck.ErrUnavailable), errors.Is(e.Err, seattlefoodtruck.ErrRateLimited),
+		errors.Is(e.Err, context.DeadlineExceeded):
+		h.lastFailure, h.lastError = h.now(), e.Err.Error()
+	}
+}
+
+//checks runs the readiness checks
+func (h *health) checks() map[string]check {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	checks := make(map[string]check)
+
+	switch {
+	case h.authFailed:
+		checks["slack"] = check{false, "slack refused the token"}
+	case h.connected:
+		checks["slack"] = check{true, "connected"}
+	default:
+		checks["slack"] = check{false, "not connected"}
+	}
+
+	switch {
+	case !h.usesAPI:
+		checks["api"] = check{true, "answering from a file"}
+	case h.lastFailure.IsZero() || h.lastFailure.Before(h.lastSuccess):
+		if h.lastSuccess.IsZero() {
+			checks["api"] = check{true, "no requests yet"}
+		} else {
+			checks["api"] = check{true, "last request succeeded " + now.Sub(h.lastSuccess).Round(time.Second).String() + " ago"}
+		}
+	case !h.lastSuccess.IsZero() && now.Sub(h.lastSuccess) < apiReadyWindow:
+		checks["api"] = check{true, "last request failed but one succeeded " + now.Sub(h.lastSuccess).Round(time.Second).String() + " ago: " + h.lastError}
+	default:
+		checks["api"] = check{false, "last request failed: " + h.lastError}
+	}
+
+	switch {
+	case !h.cronEnabled:
+		checks["cron"] = check{true, "no digest configured"}
+	case h.cronEntries() == 0:
+		checks["cron"] = check{false, "digest is not scheduled"}
+	default:
+		checks["cron"] = check{true, "digest scheduled"}
+	}
+	return checks
+}
+
+//serveHealthz reports that the process is alive
+func (h *health) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "ok",
+		"uptime": h.now().Sub(h.started).Round(time.Second).String(),
+	})
+}
+
+//serveReadyz reports whether every readiness check passes, with the detail of each check
+func (h *health) serveReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := h.checks()
+	status, code := "ok", http.StatusOK
+	var failing []string
+	for name, c := range checks {
+		if !c.OK {
+			failing = append(failing, name)
+		}
+	}
+	if len(failing) > 0 {
+		sort.Strings(failing)
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, struct {
+		Status  string           `json:"status"`
+		Failing []string         `json:"failing,omitempty"`
+		Checks  map[string]check `json:"checks"`
+	}{status, failing, checks})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//readyz requests /readyz from h and decodes the answer
+func readyz(t *testing.T, h *health) (int, map[string]check) {
+	w := httptest.NewRecorder()
+	h.serveReadyz(w, httptest.NewRequest("GET", "/readyz", nil))
+	var body struct {
+		Checks map[string]check `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected json got '%s'", w.Body.String())
+	}
+	return w.Code, body.Checks
+}
+
+func TestReadyz(t *testing.T) {
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	h := newHealth()
+	h.now = func() time.Time { return now }
+	h.watchAPI()
+	ctx := context.Background()
+	refused := &seattlefoodtruck.APIError{Endpoint: "/api/events", Err: errors.New("connection refused")}
+	entries := 1
+	h.setCron(func() int { return entries })
+
+	if code, checks := readyz(t, h); code != http.StatusServiceUnavailable || checks["slack"].OK {
+		t.Errorf("Expected not ready before connecting got %v %v", code, checks)
+	}
+
+	h.setConnected(true)
+	h.observeRequest(ctx, seattlefoodtruck.RequestEvent{Endpoint: "/api/events", StatusCode: 200})
+	if code, checks := readyz(t, h); code != http.StatusOK {
+		t.Errorf("Expected ready got %v %v", code, checks)
+	}
+
+	now = now.Add(apiReadyWindow)
+	notFound := &seattlefoodtruck.APIError{Endpoint: "/api/trucks/nosh", StatusCode: http.StatusNotFound}
+	h.observeRequest(ctx, seattlefoodtruck.RequestEvent{Endpoint: "/api/trucks/nosh", StatusCode: http.StatusNotFound, Err: notFound})
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	h.observeRequest(cancelled, seattlefoodtruck.RequestEvent{Endpoint: "/api/events",
+		Err: &seattlefoodtruck.APIError{Endpoint: "/api/events", Err: context.Canceled}})
+	if code, checks := readyz(t, h); code != http.StatusOK || !checks["api"].OK {
+		t.Errorf("Expected client errors and cancelled calls not to count against the api got %v %v", code, checks)
+	}
+
+	h.observeRequest(ctx, seattlefoodtruck.RequestEvent{Endpoint: "/api/events", StatusCode: 200})
+	now = now.Add(time.Minute)
+	h.observeRequest(ctx, seattlefoodtruck.RequestEvent{Endpoint: "/api/events", Err: refused})
+	if code, checks := readyz(t, h); code != http.StatusOK || !checks["api"].OK {
+		t.Errorf("Expected a recent success to keep the bot ready got %v %v", code, checks)
+	}
+
+	now = now.Add(apiReadyWindow)
+	h.observeRequest(ctx, seattlefoodtruck.RequestEvent{Endpoint: "/api/events", Err: refused})
+	if code, checks := readyz(t, h); code != http.StatusServiceUnavailable || checks["api"].OK {
+		t.Errorf("Expected the api check to fail got %v %v", code, checks)
+	}
+
+	h.observeRequest(ctx, seattlefoodtruck.RequestEvent{Endpoint: "/api/events", StatusCode: 200})
+	entries = 0
+	if code, checks := readyz(t, h); code != http.StatusServiceUnavailable || !checks["api"].OK || checks["cron"].OK {
+		t.Errorf("Expected only the cron check to fail got %v %v", code, checks)
+	}
+
+	h.setAuthFailed()
+	if _, checks := readyz(t, h); checks["slack"].OK {
+		t.Errorf("Expected the slack check to fail got %v", checks)
+	}
+}
+
+func TestReadyzFromFile(t *testing.T) {
+	h := newHealth()
+	h.setConnected(true)
+	code, checks := readyz(t, h)
+	if code != http.StatusOK || !checks["api"].OK || !checks["cron"].OK {
+		t.Errorf("Expected ready without the api or a digest got %v %v", code, checks)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	newHealth().serveHealthz(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected 200 json got %v %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//loggerKey is the context key of the logger carrying the fields of the current message or job
+type loggerKey struct{}
+
+//withLogger returns a context carrying l, see logger
+func withLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+//logger returns the logger of the slack message or cron run ctx belongs to, the default logger
+//outside of one
+func logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+//withFields returns a context whose logger adds args to every line
+func withFields(ctx context.Context, args ...any) context.Context {
+	return withLogger(ctx, logger(ctx).With(args...))
+}
+
+//newCorrelationID returns a random id tying together the log lines of one slack message or cron run
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+//cronContext returns a context for a run of the cron job called job, its log lines share a
+//correlation id
+func cronContext(ctx context.Context, job string) context.Context {
+	return withFields(ctx, "correlation_id", newCorrelationID(), "job", job)
+}
+
+//logRequest logs a request the proxy sent to the api, failed ones as warnings
+func logRequest(ctx context.Context, e seattlefoodtruck.RequestEvent) {
+	if e.Err != nil {
+		logger(ctx).Warn("Api request failed", "endpoint", e.Endpoint, "status", e.StatusCode, "duration", e.Duration, "error", e.Err)
+		return
+	}
+	logger(ctx).Debug("Api request", "endpoint", e.Endpoint, "status", e.StatusCode, "duration", e.Duration)
+}
+
+//newLogger creates a logger writing to w at level (debug, info, warn or error) in format (text or
+//json)
+func newLogger(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("Invalid log level %q", level)
+	}
+	options := &slog.HandlerOptions{Level: l}
+	switch strings.ToLower(format) {
+	case "text", "":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("Invalid log format %q", format)
+}
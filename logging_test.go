@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestNewLogger(t *testing.T) {
+	var out bytes.Buffer
+	l, err := newLogger(&out, "warn", "json")
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	l.Info("hidden")
+	l.Warn("shown", "endpoint", "/api/events")
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("Expected one json line got '%s'", out.String())
+	}
+	if line["msg"] != "shown" || line["endpoint"] != "/api/events" {
+		t.Errorf("Unexpected log line %v", line)
+	}
+
+	for _, c := range []struct{ level, format string }{{"loud", "text"}, {"info", "xml"}} {
+		if _, err := newLogger(&out, c.level, c.format); err == nil {
+			t.Errorf("Expected an error for level %q and format %q", c.level, c.format)
+		}
+	}
+}
+
+func TestRespondLogsWithCorrelationID(t *testing.T) {
+	var out bytes.Buffer
+	l, _ := newLogger(&out, "debug", "json")
+	f := seattlefoodtruck.NewFakeClient(todayFixture())
+	f.SetError(seattlefoodtruck.ErrUnavailable)
+	b := newBot(provider.NewSeattle(f))
+
+	ctx := withFields(withLogger(context.Background(), l), "correlation_id", "abc123", "channel", "C1", "user", "U1")
+	b.respond(ctx, "show trucks at 44")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected the api error and the answer to be logged got '%s'", out.String())
+	}
+	for _, raw := range lines {
+		var line map[string]any
+		if err := json.Unmarshal([]byte(raw), &line); err != nil {
+			t.Fatalf("Expected json lines got '%s'", raw)
+		}
+		if line["correlation_id"] != "abc123" || line["channel"] != "C1" || line["user"] != "U1" || line["command"] != "show trucks" {
+			t.Errorf("Expected every line tagged with the message and command got %v", line)
+		}
+	}
+	if !strings.Contains(out.String(), `"msg":"Food truck api error"`) {
+		t.Errorf("Expected the api error to be logged got '%s'", out.String())
+	}
+}
+
+func TestLoggerDefaultsOutsideMessages(t *testing.T) {
+	if logger(context.Background()) == nil {
+		t.Error("Expected the default logger")
+	}
+	if a, b := newCorrelationID(), newCorrelationID(); a == b || len(a) != 16 {
+		t.Errorf("Expected distinct 16 character ids got %v and %v", a, b)
+	}
+}
@@ -1,83 +1,267 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/nlopes/slack"
 	"github.com/robfig/cron"
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
 	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
 )
 
-const s3Bucket = "https://s3-us-west-2.amazonaws.com/seattlefoodtruck-uploads-prod/"
+const (
+	//commandTimeout bounds the time spent answering a single slack message
+	commandTimeout = 1 * time.Minute
+	//digestTimeout bounds the time spent building and posting the morning digest
+	digestTimeout = 5 * time.Minute
+	//digestDeadline bounds fetching the digest, leaving the rest of digestTimeout to post it
+	digestDeadline = 4 * time.Minute
+	//pollTimeout bounds a single check for schedule changes
+	pollTimeout = 2 * time.Minute
+)
 
 var (
-	rtm           *slack.RTM
-	api           *slack.Client
-	locations     []string
-	channel       string
-	token         string
-	messageParams = slack.PostMessageParameters{AsUser: true}
-	c             *cron.Cron
+	rtm            *slack.RTM
+	api            *slack.Client
+	locations      []string
+	channel        string
+	token          string
+	apiTimeout     time.Duration
+	messageParams  = slack.PostMessageParameters{AsUser: true}
+	fixture        string
+	scheduleFile   string
+	photoBase      string
+	snapshotDir    string
+	snapshotWatch  = 5 * time.Minute
+	snapshots      *snapshotWatcher
+	apiURL         string
+	configFile     string
+	apiRateLimit   float64
+	offices        []office
+	sortByDistance bool
+	pollInterval   = 15 * time.Minute
+	configWatch    = 10 * time.Second
+	httpAddr       = ":9090"
+	telemetry      = newBotMetrics()
+	status         = newHealth()
 )
 
 func init() {
-	locations = strings.Split(os.Getenv("LOCATION_IDS"), ",")
+	//LOG_LEVEL is debug, info, warn or error, LOG_FORMAT is text or json
+	level, format := os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")
+	if level == "" {
+		level = "info"
+	}
+	l, err := newLogger(os.Stderr, level, format)
+	if err != nil {
+		l, _ = newLogger(os.Stderr, "info", "text")
+		l.Warn("Ignoring invalid logging settings", "error", err)
+	}
+	slog.SetDefault(l)
+
+	//LOCATION_IDS lists the digest locations by uid or name, e.g. 44,amazon doppler
+	for _, l := range strings.Split(os.Getenv("LOCATION_IDS"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			locations = append(locations, l)
+		}
+	}
 	channel = os.Getenv("CHANNEL")
 	token = os.Getenv("SLACK_TOKEN")
+	//CONFIG_FILE points at a yaml file defining the digests, LOCATION_IDS and CHANNEL are then ignored
+	configFile = os.Getenv("CONFIG_FILE")
+	//CONFIG_WATCH_INTERVAL is how often CONFIG_FILE is checked for changes, 0 only reloads on SIGHUP
+	if v := os.Getenv("CONFIG_WATCH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			slog.Warn("Ignoring invalid CONFIG_WATCH_INTERVAL", "value", v)
+		} else {
+			configWatch = d
+		}
+	}
+	//FIXTURE points at a json fixture to answer from instead of seattlefoodtruck.com, for demos
+	fixture = os.Getenv("FIXTURE")
+	//SCHEDULE_FILE points at a json or csv schedule the office maintains itself, for cities without
+	//seattlefoodtruck.com. SCHEDULE_PHOTOS is the address relative photo paths in it are under
+	scheduleFile = os.Getenv("SCHEDULE_FILE")
+	photoBase = os.Getenv("SCHEDULE_PHOTOS")
+	//SNAPSHOT_DIR answers every command from the latest snapshot written there by foodtruck-snapshot
+	snapshotDir = os.Getenv("SNAPSHOT_DIR")
+	//SNAPSHOT_REFRESH_INTERVAL is how often SNAPSHOT_DIR is checked for a newer snapshot, 0 only checks
+	//on SIGHUP
+	if v := os.Getenv("SNAPSHOT_REFRESH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			slog.Warn("Ignoring invalid SNAPSHOT_REFRESH_INTERVAL", "value", v)
+		} else {
+			snapshotWatch = d
+		}
+	}
+	//SEATTLEFOODTRUCK_API overrides the api address, e.g. to use the local foodtruck-emulator
+	apiURL = os.Getenv("SEATTLEFOODTRUCK_API")
+	if apiURL == "" {
+		apiURL = "https://www.seattlefoodtruck.com"
+	}
+	//TIMEZONE is the zone used to decide which day an event happens on, America/Los_Angeles by default
+	if v := os.Getenv("TIMEZONE"); v != "" {
+		loc, err := time.LoadLocation(v)
+		if err != nil {
+			slog.Warn("Ignoring invalid TIMEZONE", "error", err)
+		} else {
+			seattlefoodtruck.SetTimezone(loc)
+		}
+	}
+	//API_RATE_LIMIT is how many food truck api requests per second the bot makes at most, 0 disables
+	//the limit
+	apiRateLimit = seattlefoodtruck.DefaultRequestsPerSecond
+	if v := os.Getenv("API_RATE_LIMIT"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 {
+			slog.Warn("Ignoring invalid API_RATE_LIMIT", "value", v)
+		} else {
+			apiRateLimit = r
+		}
+	}
+	//OFFICES lists where the team walks from as name=lat,long separated by semicolons
+	if v := os.Getenv("OFFICES"); v != "" {
+		o, err := parseOffices(v)
+		if err != nil {
+			slog.Warn("Ignoring invalid OFFICES", "error", err)
+		} else {
+			offices = o
+		}
+	}
+	//SORT_BY_DISTANCE lists the locations closest to an office first
+	sortByDistance, _ = strconv.ParseBool(os.Getenv("SORT_BY_DISTANCE"))
+	//CHANGE_POLL_INTERVAL is how often today's schedule at the digest locations is checked for
+	//added and cancelled trucks, 0 turns the alerts off
+	if v := os.Getenv("CHANGE_POLL_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			slog.Warn("Ignoring invalid CHANGE_POLL_INTERVAL", "value", v)
+		} else {
+			pollInterval = d
+		}
+	}
+	//HTTP_ADDR is where /metrics, /healthz and /readyz are served, off turns it off
+	if v := os.Getenv("HTTP_ADDR"); v != "" {
+		httpAddr = v
+	}
+	//API_TIMEOUT is the default timeout for a single food truck api request, e.g. 20s
+	if v := os.Getenv("API_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			slog.Warn("Ignoring invalid API_TIMEOUT", "error", err)
+		} else {
+			apiTimeout = d
+		}
+	}
 }
 
 func main() {
-	api = slack.New(token)
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	api = slack.New(cfg.SlackToken)
 	rtm = api.NewRTM()
 
-	if len(locations) > 0 && channel != "" {
-		fmt.Println("Creating a new instance of Cron Scheduler")
-		c = cron.New()
-		c.AddFunc("0 0 08 * * mon-fri", func() {
-			fmt.Println("Executing func in Cron")
-			message, err := showTrucksForLocations(locations)
-			if err != nil {
-				fmt.Println("Failed to get trucks for locations")
-			} else {
-				log.Println("Message : ", message)
-				responseHandler(channel, message)
-			}
-		})
-		//Start the Cron
-		fmt.Println("Starting Cron")
-		c.Start()
+	source, err := newProvider()
+	if err != nil {
+		slog.Error("Failed to create food truck provider", "error", err)
+		os.Exit(1)
 	}
+	b := newBot(source)
+	b.offices = offices
+	b.sortByDistance = sortByDistance
+	b.commands = telemetry.commands
+	telemetry.watchCache(source)
+
+	//ctx is cancelled when the bot shuts down so in flight api calls are abandoned
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if httpAddr != "off" {
+		go serveStatus(ctx, httpAddr, telemetry, status)
+	}
+
+	digests := newDigestRunner(ctx, b)
+	digests.apply(cfg)
+	defer digests.stop()
+	if configFile != "" && configWatch > 0 {
+		go watchConfig(ctx, configFile, configWatch, func() { digests.reload(configFile) })
+	}
+	if snapshots != nil && snapshotWatch > 0 {
+		go snapshots.watch(ctx, snapshotWatch)
+	}
+	//SIGHUP reloads the configuration and picks up newer snapshots
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 
 	go rtm.ManageConnection()
 
 Loop:
 	for {
 		select {
+		case <-ctx.Done():
+			slog.Info("Shutting down")
+			break Loop
+
+		case <-hup:
+			slog.Info("Reloading configuration on SIGHUP")
+			digests.reload(configFile)
+			if snapshots != nil {
+				snapshots.refresh()
+			}
+
 		case msg := <-rtm.IncomingEvents:
 			switch ev := msg.Data.(type) {
 			case *slack.ConnectedEvent:
-				fmt.Println("Connection Count: ", ev.ConnectionCount)
+				slog.Info("Connected to slack", "connection_count", ev.ConnectionCount)
+				telemetry.connections.Inc()
+				status.setConnected(true)
+
+			case *slack.DisconnectedEvent:
+				slog.Warn("Disconnected from slack", "intentional", ev.Intentional)
+				status.setConnected(false)
 
 			case *slack.MessageEvent:
-				fmt.Printf("Incoming message: %v\n", ev)
 				info := rtm.GetInfo()
 				prefix := fmt.Sprintf("<@%s> ", info.User.ID)
 
 				//only respond if @mention user is same as bot user id, we don't want to respond to other messages on channel
 				if ev.User != info.User.ID && strings.HasPrefix(ev.Text, prefix) {
-					respond(rtm, ev, prefix)
+					go func() {
+						msgCtx := withFields(ctx, "correlation_id", newCorrelationID(), "channel", ev.Channel, "user", ev.User)
+						msgCtx, msgCancel := context.WithTimeout(msgCtx, commandTimeout)
+						defer msgCancel()
+						logger(msgCtx).Debug("Received command", "text_bytes", len(ev.Text))
+						response := b.respond(msgCtx, strings.TrimPrefix(ev.Text, prefix))
+						rtm.SendMessage(rtm.NewOutgoingMessage(response, ev.Channel))
+					}()
 				}
 
 			case *slack.RTMError:
-				fmt.Printf("Error: %s\n", ev.Error())
+				slog.Error("Slack connection error", "error", ev.Error())
 
 			case *slack.InvalidAuthEvent:
-				fmt.Printf("Invalid credentials")
+				slog.Error("Invalid slack credentials")
+				status.setAuthFailed()
+				if httpAddr != "off" {
+					//keep serving /readyz so the refused token is reported, posting nothing until shut down
+					digests.stop()
+					slog.Info("Serving health checks until shut down", "addr", httpAddr)
+					<-ctx.Done()
+				}
 				break Loop
 
 			default:
@@ -86,176 +270,121 @@ Loop:
 		}
 	}
 }
-func respond(rtm *slack.RTM, msg *slack.MessageEvent, prefix string) {
-	text := msg.Text
-	text = strings.TrimPrefix(text, prefix)
-	text = strings.TrimSpace(text)
-	text = strings.ToLower(text)
-
-	if text == "help" {
-		response := fmt.Sprintf("%s: \n", "*You can ask me*")
-		response += fmt.Sprintf("%s \n", "• *show neighborhoods* - to see neighborhoods served")
-		response += fmt.Sprintf("%s \n", "• *show locations in <neighborhood>* - to see food truck locations in a neighborhood")
-		response += fmt.Sprintf("%s \n", "• *show trucks at <location>* - to see food trucks at a location")
 
-		rtm.SendMessage(rtm.NewOutgoingMessage(response, msg.Channel))
-	} else if text == "show neighborhoods" {
-		showNeighborhoods(rtm, msg.Channel)
-	} else if strings.Contains(text, "show locations") {
-		showLocations(rtm, text, msg.Channel)
-	} else if strings.Contains(text, "show trucks") {
-		showTrucks(rtm, text, msg.Channel)
-	} else {
-		rtm.SendMessage(rtm.NewOutgoingMessage("Sorry I cannot help you with this, please try help to see things you can ask me", msg.Channel))
-	}
-}
-func showNeighborhoods(rtm *slack.RTM, channel string) {
-	var message string
-	p, _ := seattlefoodtruck.NewProxy("https://www.seattlefoodtruck.com")
-	resp, err := p.GetNeighborhoods()
-	if err != nil {
-		rtm.SendMessage(rtm.NewOutgoingMessage(err.Error(), channel))
-		return
-	}
-	if len(resp.Neighborhoods) == 0 {
-		message = fmt.Sprintf("%s \n", "No Neighborhoods found")
-		rtm.SendMessage(rtm.NewOutgoingMessage(message, channel))
-		return
+//scheduleDigest adds the jobs posting digest d and the schedule change alerts of poller to c
+func scheduleDigest(ctx context.Context, c *cron.Cron, b *bot, d digestConfig, poller *changePoller) {
+	db := *b
+	if d.SortByDistance != nil {
+		db.sortByDistance = *d.SortByDistance
 	}
-	//show all neighborhoods
-	message = fmt.Sprintf("%s \n", "*You can find food trucks in following neighborhoods*")
-	for _, n := range resp.Neighborhoods {
-		message += fmt.Sprintf("• %s \n", n.ID)
+	ctx = withFields(ctx, "digest", d.Name)
+	c.Schedule(d.schedule, cron.FuncJob(func() {
+		jobCtx, jobCancel := context.WithTimeout(cronContext(ctx, "digest"), digestTimeout)
+		defer jobCancel()
+		logger(jobCtx).Info("Posting the morning digest")
+		message, err := db.showTrucksForLocations(jobCtx, d.Locations)
+		if err != nil {
+			logger(jobCtx).Error("Failed to get trucks for locations", "error", err)
+			telemetry.digests.WithLabelValues("failure").Inc()
+			return
+		}
+		if d.Title != "" {
+			message = fmt.Sprintf("*%s* \n", d.Title) + message
+		}
+		ts, err := responseHandler(jobCtx, d.Channel, message, "")
+		if err != nil {
+			telemetry.digests.WithLabelValues("failure").Inc()
+			return
+		}
+		telemetry.digests.WithLabelValues("success").Inc()
+		poller.setThread(ts)
+	}))
+	if pollInterval > 0 && d.changeAlerts() {
+		c.AddFunc("@every "+pollInterval.String(), func() {
+			jobCtx, jobCancel := context.WithTimeout(cronContext(ctx, "schedule changes"), pollTimeout)
+			defer jobCancel()
+			changes, err := poller.poll(jobCtx)
+			if err != nil {
+				logger(jobCtx).Warn("Failed to check for schedule changes", "error", err)
+			} else if len(changes) > 0 {
+				logger(jobCtx).Info("Posting schedule changes", "changes", len(changes))
+				if _, err := responseHandler(jobCtx, d.Channel, changesMessage(changes), poller.threadTimestamp()); err != nil {
+					logger(jobCtx).Error("Failed to post schedule changes", "changes", len(changes), "error", err)
+					telemetry.alerts.WithLabelValues("failure").Inc()
+					return
+				}
+				telemetry.alerts.WithLabelValues("success").Inc()
+			}
+		})
 	}
-	rtm.SendMessage(rtm.NewOutgoingMessage(message, channel))
 }
 
-func showLocations(rtm *slack.RTM, text string, channel string) {
-	var message string
-	tokens := strings.Split(text, "in")
-	if len(tokens) < 2 {
-		rtm.SendMessage(rtm.NewOutgoingMessage("Missing Neighborhood", channel))
-		return
-	}
-	neighborhood := strings.TrimSpace(tokens[1])
-	if len(neighborhood) == 0 {
-		rtm.SendMessage(rtm.NewOutgoingMessage("Missing neighborhood", channel))
-		return
+//newProvider creates the source of food truck schedules the bot answers from
+func newProvider() (provider.Provider, error) {
+	if scheduleFile != "" {
+		slog.Info("Answering from schedule file", "path", scheduleFile)
+		f, err := provider.NewFile(scheduleFile)
+		if err != nil {
+			return nil, err
+		}
+		f.PhotoBase = photoBase
+		return f, nil
 	}
-	p, _ := seattlefoodtruck.NewProxy("https://www.seattlefoodtruck.com")
-	lr := seattlefoodtruck.LocationRequest{
-		Page:         1,
-		Neighborhood: neighborhood,
+	if snapshotDir != "" {
+		w, err := newSnapshotWatcher(snapshotDir)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = w
+		return w.file, nil
 	}
-	resp, err := p.GetLocations(&lr)
+	client, err := newClient()
 	if err != nil {
-		rtm.SendMessage(rtm.NewOutgoingMessage(err.Error(), channel))
-		return
-	}
-	if len(resp.Locations) == 0 {
-		message = fmt.Sprintf("No locations found at %s neighborhood \n", neighborhood)
-		rtm.SendMessage(rtm.NewOutgoingMessage(message, channel))
-		return
+		return nil, err
 	}
-	message = fmt.Sprintf("%s \n", "*You can find food trucks in following locations*")
-	for _, l := range resp.Locations {
-		message += fmt.Sprintf("• %s - %v \n", l.Name, l.UID)
-	}
-	rtm.SendMessage(rtm.NewOutgoingMessage(message, channel))
+	return provider.NewSeattle(client), nil
 }
 
-func showTrucks(rtm *slack.RTM, text string, channel string) {
-	var message string
-	//extract location id from text
-	tokens := strings.Split(text, "at")
-	if len(tokens) < 2 {
-		rtm.SendMessage(rtm.NewOutgoingMessage("Missing location", channel))
-		return
-	}
-	locString := strings.TrimSpace(tokens[1])
-	if len(locString) == 0 {
-		rtm.SendMessage(rtm.NewOutgoingMessage("Missing location", channel))
-		return
+//newClient creates the seattlefoodtruck.com client the bot answers from
+func newClient() (seattlefoodtruck.Client, error) {
+	if fixture != "" {
+		slog.Info("Answering from fixture", "path", fixture)
+		return seattlefoodtruck.NewFakeClientFromFile(fixture)
 	}
-	message = getTrucksForLocation(locString)
-	//send message to channel
-	rtm.SendMessage(rtm.NewOutgoingMessage(message, channel))
-}
-
-func getTrucksForLocation(locString string) (message string) {
-	location, _ := strconv.Atoi(locString)
-	p, _ := seattlefoodtruck.NewProxy("https://www.seattlefoodtruck.com")
-	req := seattlefoodtruck.NewLocationEventsRequest(location, 1)
-	resp, err := p.GetLocationEvents(&req)
+	p, err := seattlefoodtruck.NewProxy(apiURL)
 	if err != nil {
-		message = err.Error()
-		return
+		return nil, err
 	}
-	if len(resp.Events) == 0 {
-		message = fmt.Sprintf("No events at %v", locString)
-		return
+	if apiTimeout != 0 {
+		p.Timeout = apiTimeout
 	}
-	events := find(resp.Events, filterByStartDate)
-	if events == nil {
-		message = fmt.Sprintf("No food trucks found at %v", locString)
-		return
+	if apiRateLimit > 0 {
+		p.RateLimit = seattlefoodtruck.NewRateLimiter(apiRateLimit, int(math.Ceil(apiRateLimit)))
+	} else {
+		p.RateLimit = nil
 	}
-	message = ""
-	for eventIndex := range events {
-		event := resp.Events[eventIndex]
-		if len(event.Bookings) != 0 {
-			st, _ := time.Parse(time.RFC3339, event.StartTime)
-			et, _ := time.Parse(time.RFC3339, event.EndTime)
-			_, m, d := st.Date()
-			message += fmt.Sprintf("*%s* \t %v %v %v - %v \n", event.Location.Name, m, d, st.Format(time.Kitchen), et.Format(time.Kitchen))
-
-			for _, b := range event.Bookings {
-				message += fmt.Sprintf("*%v* (%s) %v \n", b.Truck.Name,
-					strings.Join(b.Truck.FoodCategories, ", "), s3Bucket+b.Truck.FeaturedPhoto)
-			}
-		}
+	p.Retry.OnRetry = func(e seattlefoodtruck.RetryEvent) {
+		logger(e.Ctx).Debug("Retrying api request", "endpoint", e.Endpoint, "attempt", e.Attempt, "delay", e.Delay)
 	}
-	return message
-}
-
-//returns a slice of indeces of found events matching the passed function, if none returns nil
-func find(events []seattlefoodtruck.Event, f func(seattlefoodtruck.Event) bool) []int {
-	var foundEvents []int
-	for i, e := range events {
-		if f(e) {
-			foundEvents = append(foundEvents, i)
-		}
+	p.OnRequest = func(ctx context.Context, e seattlefoodtruck.RequestEvent) {
+		telemetry.observeRequest(e)
+		status.observeRequest(ctx, e)
+		logRequest(ctx, e)
 	}
-	return foundEvents
+	status.watchAPI()
+	return p, nil
 }
 
-// used to filter by event start date
-func filterByStartDate(event seattlefoodtruck.Event) bool {
-	ct := time.Now().Local()
-	y1, m1, d1 := ct.Date()
-	//parse start time
-	st, _ := time.Parse(time.RFC3339, event.StartTime)
-	y2, m2, d2 := st.Date()
-	if y1 == y2 && m1 == m2 && d1 == d2 {
-		return true
-	}
-	return false
-}
-
-func showTrucksForLocations(locations []string) (string, error) {
-	var message string
-	if len(locations) == 0 {
-		fmt.Printf("No locations set \n")
-		return "", fmt.Errorf("No locations to show trucks for")
-	}
-	for _, l := range locations {
-		fmt.Printf("Getting trucks for location: %v \n", l)
-		message += fmt.Sprintf("%s \n", getTrucksForLocation(l))
+//responseHandler posts message to channel, as a reply in thread unless it is empty, and returns the
+//timestamp of the posted message
+func responseHandler(ctx context.Context, channel string, message string, thread string) (string, error) {
+	params := messageParams
+	params.ThreadTimestamp = thread
+	_, ts, err := api.PostMessageContext(ctx, channel, message, params)
+	if err != nil {
+		logger(ctx).Error("Failed to post message", "channel", channel, "error", err)
+	} else {
+		logger(ctx).Debug("Posted message", "channel", channel, "message_bytes", len(message), "thread", thread)
 	}
-	return message, nil
-}
-
-func responseHandler(channel string, message string) {
-	fmt.Printf("Posting message %s to slack %s \n", message, channel)
-	api.PostMessage(channel, message, messageParams)
+	return ts, err
 }
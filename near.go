@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/geo"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//defaultNearDistance is how far trucks near looks when no distance is given
+const defaultNearDistance = geo.Kilometer
+
+//nearbyLocation is a location and how far it is from where the user asked about
+type nearbyLocation struct {
+	location seattlefoodtruck.Location
+	distance geo.Distance
+}
+
+//locationPoint returns the coordinates of a location, invalid when the api did not send them
+func locationPoint(l seattlefoodtruck.Location) geo.Point {
+	return geo.Point{Latitude: l.Latitude, Longitude: l.Longitude}
+}
+
+//trucksNear lists today's trucks at locations within a distance of a location or coordinate, text
+//is "<location|lat,long> within <distance>"
+func (b *bot) trucksNear(ctx context.Context, text string) string {
+	where, within := strings.TrimSpace(text), ""
+	if i := strings.LastIndex(where, " within "); i >= 0 {
+		where, within = strings.TrimSpace(where[:i]), strings.TrimSpace(where[i+len(" within "):])
+	}
+	if len(where) == 0 {
+		return "Missing location, try *trucks near <location|lat,long> within <distance>*"
+	}
+	radius := defaultNearDistance
+	if len(within) != 0 {
+		d, err := geo.ParseDistance(within)
+		if err != nil {
+			return err.Error()
+		}
+		radius = d
+	}
+	locations, err := b.allLocations(ctx)
+	if err != nil {
+		return errorMessage(ctx, err)
+	}
+	origin, label, message := b.resolvePoint(ctx, where, locations)
+	if !origin.Valid() {
+		return message
+	}
+	nearby := locationsWithin(locations, origin, radius)
+	if len(nearby) == 0 {
+		return fmt.Sprintf("No food truck locations within %v of %s", radius, label)
+	}
+	uids := make([]int, len(nearby))
+	for i, n := range nearby {
+		uids[i] = n.location.UID
+	}
+	query := seattlefoodtruck.NewEventsQuery().ForLocations(uids...).On(time.Now()).
+		WithActiveTrucks(true).WithBookingStatus(seattlefoodtruck.BookingApproved)
+	allEvents, err := b.source.Events(ctx, query)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Showing partial list of events", "error", err)
+	} else if err != nil {
+		return errorMessage(ctx, err)
+	}
+	byLocation := make(map[int][]seattlefoodtruck.Event)
+	for _, e := range find(allEvents, filterByStartDate) {
+		event := allEvents[e]
+		if len(event.Bookings) != 0 {
+			byLocation[event.Location.UID] = append(byLocation[event.Location.UID], event)
+		}
+	}
+	message = ""
+	for _, n := range nearby {
+		for _, event := range byLocation[n.location.UID] {
+			message += b.eventMessage(event, fmt.Sprintf("%s (%v)", n.location.Name, n.distance))
+		}
+	}
+	if len(message) == 0 {
+		return fmt.Sprintf("No food trucks today within %v of %s", radius, label)
+	}
+	return fmt.Sprintf("*Food trucks within %v of %s* \n", radius, label) + message
+}
+
+//resolvePoint turns a coordinate or a location name into a point. When it cannot the point is
+//invalid and message says why
+func (b *bot) resolvePoint(ctx context.Context, where string, locations []seattlefoodtruck.Location) (geo.Point, string, string) {
+	if p, err := geo.ParsePoint(where); err == nil {
+		return p, p.String(), ""
+	} else if strings.Contains(where, ",") {
+		return geo.Point{}, "", err.Error()
+	}
+	location, message := b.resolveLocation(ctx, where, "trucks near")
+	if location == nil {
+		return geo.Point{}, "", message
+	}
+	for _, l := range locations {
+		if l.UID == location.UID && locationPoint(l).Valid() {
+			return locationPoint(l), l.Name, ""
+		}
+	}
+	return geo.Point{}, "", fmt.Sprintf("I don't know where %s is", where)
+}
+
+//locationsWithin returns the locations within radius of origin, closest first
+func locationsWithin(locations []seattlefoodtruck.Location, origin geo.Point, radius geo.Distance) []nearbyLocation {
+	var nearby []nearbyLocation
+	for _, l := range locations {
+		p := locationPoint(l)
+		if !p.Valid() {
+			continue
+		}
+		if d := geo.Between(origin, p); d <= radius {
+			nearby = append(nearby, nearbyLocation{location: l, distance: d})
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].distance < nearby[j].distance })
+	return nearby
+}
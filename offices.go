@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rprakashg/foodtruck-slack-bot/geo"
+)
+
+//walkingSpeed is a relaxed walking pace in meters per minute
+const walkingSpeed = 80
+
+//office is a place the team walks to the trucks from
+type office struct {
+	name  string
+	point geo.Point
+}
+
+//parseOffices reads offices written as name=lat,long separated by semicolons, e.g.
+//doppler=47.6159,-122.3391;day1=47.6163,-122.3380. The name may be left out when there is one office
+func parseOffices(value string) ([]office, error) {
+	var offices []office
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		name, coordinate := "the office", entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			name, coordinate = strings.TrimSpace(entry[:i]), entry[i+1:]
+		}
+		p, err := geo.ParsePoint(coordinate)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid office %q: %v", entry, err)
+		}
+		offices = append(offices, office{name: name, point: p})
+	}
+	return offices, nil
+}
+
+//walkingMinutes estimates how long walking a straight line of d takes, rounded up
+func walkingMinutes(d geo.Distance) int {
+	return int(math.Ceil(float64(d) / walkingSpeed))
+}
+
+//nearestOffice returns the office closest to p and how far it is, ok is false when there are no
+//offices or p is unknown
+func (b *bot) nearestOffice(p geo.Point) (office, geo.Distance, bool) {
+	var nearest office
+	best := geo.Distance(math.Inf(1))
+	if !p.Valid() {
+		return nearest, best, false
+	}
+	for _, o := range b.offices {
+		if d := geo.Between(o.point, p); d < best {
+			nearest, best = o, d
+		}
+	}
+	return nearest, best, len(b.offices) > 0
+}
+
+//distanceNote describes how far p is from the nearest office, e.g. " (350 m, 5 min walk from hq)",
+//empty when that is unknown
+func (b *bot) distanceNote(p geo.Point) string {
+	o, d, ok := b.nearestOffice(p)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (%v, %d min walk from %s)", d, walkingMinutes(d), o.name)
+}
+
+//officeDistance returns how far p is from the nearest office, unknown distances sort last
+func (b *bot) officeDistance(p geo.Point) geo.Distance {
+	_, d, ok := b.nearestOffice(p)
+	if !ok {
+		return geo.Distance(math.Inf(1))
+	}
+	return d
+}
+
+//locationPoints returns the coordinates of every known location by uid. Only needed when offices
+//are configured, failures leave listings without distances
+func (b *bot) locationPoints(ctx context.Context) map[int]geo.Point {
+	points := make(map[int]geo.Point)
+	if len(b.offices) == 0 {
+		return points
+	}
+	locations, err := b.allLocations(ctx)
+	if err != nil {
+		logger(ctx).Warn("Listing without office distances", "error", err)
+		return points
+	}
+	for _, l := range locations {
+		if p := locationPoint(l); p.Valid() {
+			points[l.UID] = p
+		}
+	}
+	return points
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rprakashg/foodtruck-slack-bot/geo"
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestParseOffices(t *testing.T) {
+	offices, err := parseOffices("doppler=47.6159,-122.3391; factoria = 47.5778,-122.1707;")
+	if err != nil || len(offices) != 2 {
+		t.Fatalf("Expected 2 offices got %v, %v", offices, err)
+	}
+	if offices[1].name != "factoria" || offices[1].point != (geo.Point{Latitude: 47.5778, Longitude: -122.1707}) {
+		t.Errorf("Expected the factoria office got %+v", offices[1])
+	}
+	offices, err = parseOffices("47.6159,-122.3391")
+	if err != nil || len(offices) != 1 || offices[0].name != "the office" {
+		t.Errorf("Expected one unnamed office got %v, %v", offices, err)
+	}
+	if _, err := parseOffices("hq=somewhere"); err == nil {
+		t.Errorf("Expected an error for an invalid coordinate")
+	}
+}
+
+func TestWalkingMinutes(t *testing.T) {
+	if got := walkingMinutes(0); got != 0 {
+		t.Errorf("Expected 0 minutes got %d", got)
+	}
+	if got := walkingMinutes(81); got != 2 {
+		t.Errorf("Expected 2 minutes got %d", got)
+	}
+}
+
+func TestOfficeDistances(t *testing.T) {
+	f := namesFixture()
+	today := f.Events[1]
+	today.ID, today.Location = 3, f.Locations[2] //Amazon Doppler
+	f.Events = append(f.Events, today)
+	b := newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(f)))
+	b.offices = []office{{name: "day1", point: geo.Point{Latitude: 47.6163, Longitude: -122.3380}}}
+
+	got := b.respond(context.Background(), "show trucks at 88")
+	if !strings.Contains(got, "*Amazon Doppler (") || !strings.Contains(got, "min walk from day1)*") {
+		t.Errorf("Expected walking distance in '%v'", got)
+	}
+	got = b.respond(context.Background(), "show locations in bellevue")
+	if !strings.Contains(got, "T-Mobile Factoria - 44 (") {
+		t.Errorf("Expected walking distance in '%v'", got)
+	}
+
+	digest, err := b.showTrucksForLocations(context.Background(), []string{"44", "88"})
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if strings.Index(digest, "*T-Mobile Factoria") > strings.Index(digest, "*Amazon Doppler") {
+		t.Errorf("Expected configured order without sorting in '%v'", digest)
+	}
+	b.sortByDistance = true
+	digest, _ = b.showTrucksForLocations(context.Background(), []string{"44", "88"})
+	if strings.Index(digest, "*T-Mobile Factoria") < strings.Index(digest, "*Amazon Doppler") {
+		t.Errorf("Expected the closest location first in '%v'", digest)
+	}
+	got = b.respond(context.Background(), "show locations in bellevue")
+	if strings.Index(got, "Bellevue City Hall") > strings.Index(got, "T-Mobile Factoria") {
+		t.Errorf("Expected City Hall, the closer location, first in '%v'", got)
+	}
+}
@@ -0,0 +1,330 @@
+package provider
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//File provides schedules an office maintains itself, read from a json fixture or a csv file. It is
+//safe for concurrent use
+type File struct {
+	mu      sync.RWMutex
+	fixture seattlefoodtruck.Fixture
+	//PhotoBase is prepended to photo paths that are not links already
+	PhotoBase string
+}
+
+var _ Provider = (*File)(nil)
+
+//NewFile reads schedules from a .csv file, any other file is read as a json fixture shaped like
+//the seattlefoodtruck api responses
+func NewFile(path string) (*File, error) {
+	var fixture seattlefoodtruck.Fixture
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		var f *os.File
+		f, err = os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		fixture, err = ParseCSV(f)
+	} else {
+		fixture, err = seattlefoodtruck.LoadFixture(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid schedule file %s: %v", path, err)
+	}
+	return NewFileFromFixture(fixture), nil
+}
+
+//NewFileFromFixture provides schedules from fixture. Trucks booked at events but missing from the
+//fixture's trucks are added with what the bookings say about them
+func NewFileFromFixture(fixture seattlefoodtruck.Fixture) *File {
+	return &File{fixture: withBookedTrucks(fixture)}
+}
+
+//Swap replaces the schedules with those of fixture, e.g. when a newer snapshot is written
+func (f *File) Swap(fixture seattlefoodtruck.Fixture) {
+	fixture = withBookedTrucks(fixture)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fixture = fixture
+}
+
+//data returns the schedules currently provided
+func (f *File) data() seattlefoodtruck.Fixture {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.fixture
+}
+
+//withBookedTrucks adds the trucks booked at events but missing from the fixture's trucks
+func withBookedTrucks(fixture seattlefoodtruck.Fixture) seattlefoodtruck.Fixture {
+	known := make(map[string]bool)
+	for _, t := range fixture.Trucks {
+		known[strings.ToLower(t.ID)] = true
+	}
+	var trucks []seattlefoodtruck.Truck
+	for _, e := range fixture.Events {
+		for _, b := range e.Bookings {
+			id := strings.ToLower(b.Truck.ID)
+			if len(id) == 0 || known[id] {
+				continue
+			}
+			known[id] = true
+			trucks = append(trucks, seattlefoodtruck.Truck{Name: b.Truck.Name, ID: b.Truck.ID, UID: b.Truck.UID,
+				Trailer: b.Truck.Trailer, FoodCategories: b.Truck.FoodCategories, FeaturedPhoto: b.Truck.FeaturedPhoto})
+		}
+	}
+	fixture.Trucks = append(append([]seattlefoodtruck.Truck(nil), fixture.Trucks...), trucks...)
+	return fixture
+}
+
+//Neighborhoods returns the neighborhoods in the file
+func (f *File) Neighborhoods(ctx context.Context) ([]seattlefoodtruck.Neighborhood, error) {
+	return f.data().Neighborhoods, ctx.Err()
+}
+
+//Locations returns the locations in a neighborhood
+func (f *File) Locations(ctx context.Context, neighborhood string) ([]seattlefoodtruck.Location, error) {
+	return f.data().LocationsIn(neighborhood), ctx.Err()
+}
+
+//Events returns the events matching query
+func (f *File) Events(ctx context.Context, query seattlefoodtruck.EventsQuery) ([]seattlefoodtruck.Event, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return query.Apply(f.data().Events), ctx.Err()
+}
+
+//Trucks returns the trucks in the file
+func (f *File) Trucks(ctx context.Context) ([]seattlefoodtruck.Truck, error) {
+	return f.data().Trucks, ctx.Err()
+}
+
+//Truck returns the profile of a truck
+func (f *File) Truck(ctx context.Context, id string) (seattlefoodtruck.Truck, error) {
+	if err := ctx.Err(); err != nil {
+		return seattlefoodtruck.Truck{}, err
+	}
+	t, ok := f.data().Truck(id)
+	if !ok {
+		return t, fmt.Errorf("No truck %s: %w", id, seattlefoodtruck.ErrNotFound)
+	}
+	return t, nil
+}
+
+//PhotoURL links a photo path to PhotoBase
+func (f *File) PhotoURL(path string) string {
+	return photoURL(f.PhotoBase, path)
+}
+
+//csvColumns are the columns of a schedule csv file, the ones marked true must be present
+var csvColumns = map[string]bool{
+	"date":         true,
+	"start":        true,
+	"end":          true,
+	"neighborhood": true,
+	"location":     true,
+	"truck":        true,
+	"address":      false,
+	"latitude":     false,
+	"longitude":    false,
+	"categories":   false,
+	"photo":        false,
+}
+
+//ParseCSV reads a schedule with one booking per row. The first row names the columns:
+//date (2006-01-02), start and end (15:04 or 3:04PM, in seattlefoodtruck.Timezone), neighborhood,
+//location and truck, optionally address, latitude, longitude, categories separated by semicolons
+//and photo. Rows at the same location and times are one event
+func ParseCSV(r io.Reader) (seattlefoodtruck.Fixture, error) {
+	var fixture seattlefoodtruck.Fixture
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return fixture, fmt.Errorf("Missing header row: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := csvColumns[name]; !ok {
+			return fixture, fmt.Errorf("Unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	for name, required := range csvColumns {
+		if _, ok := columns[name]; required && !ok {
+			return fixture, fmt.Errorf("Missing column %q", name)
+		}
+	}
+
+	neighborhoods := make(map[string]int)
+	locations := make(map[string]int)
+	trucks := make(map[string]int)
+	events := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fixture, err
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		for name, required := range csvColumns {
+			if required && len(field(name)) == 0 {
+				return fixture, fmt.Errorf("Line %d: missing %s", line, name)
+			}
+		}
+		start, end, err := parseTimes(field("date"), field("start"), field("end"))
+		if err != nil {
+			return fixture, fmt.Errorf("Line %d: %v", line, err)
+		}
+
+		n, ok := neighborhoods[slug(field("neighborhood"))]
+		if !ok {
+			n = len(fixture.Neighborhoods)
+			neighborhoods[slug(field("neighborhood"))] = n
+			fixture.Neighborhoods = append(fixture.Neighborhoods, seattlefoodtruck.Neighborhood{
+				Name: field("neighborhood"), ID: slug(field("neighborhood")), UID: n + 1})
+		}
+		l, ok := locations[slug(field("location"))]
+		if !ok {
+			l = len(fixture.Locations)
+			locations[slug(field("location"))] = l
+			fixture.Locations = append(fixture.Locations, seattlefoodtruck.Location{
+				Name: field("location"), ID: slug(field("location")), Slug: slug(field("location")), UID: l + 1,
+				NeighborhoodID: fixture.Neighborhoods[n].UID})
+		}
+		location := &fixture.Locations[l]
+		if address := field("address"); len(address) != 0 && len(location.Address) == 0 {
+			location.Address, location.FilteredAddress = address, address
+		}
+		if lat, long := field("latitude"), field("longitude"); len(lat) != 0 || len(long) != 0 {
+			if location.Latitude, err = strconv.ParseFloat(lat, 64); err != nil {
+				return fixture, fmt.Errorf("Line %d: invalid latitude %q", line, lat)
+			}
+			if location.Longitude, err = strconv.ParseFloat(long, 64); err != nil {
+				return fixture, fmt.Errorf("Line %d: invalid longitude %q", line, long)
+			}
+		}
+
+		t, ok := trucks[slug(field("truck"))]
+		if !ok {
+			t = len(fixture.Trucks)
+			trucks[slug(field("truck"))] = t
+			fixture.Trucks = append(fixture.Trucks, seattlefoodtruck.Truck{Name: field("truck"), ID: slug(field("truck")), UID: t + 1})
+		}
+		truck := &fixture.Trucks[t]
+		if categories := splitCategories(field("categories")); len(categories) != 0 && len(truck.FoodCategories) == 0 {
+			truck.FoodCategories = categories
+		}
+		if photo := field("photo"); len(photo) != 0 && len(truck.FeaturedPhoto) == 0 {
+			truck.FeaturedPhoto = photo
+		}
+
+		key := fmt.Sprintf("%d %v %v", location.UID, start.Unix(), end.Unix())
+		e, ok := events[key]
+		if !ok {
+			e = len(fixture.Events)
+			events[key] = e
+			fixture.Events = append(fixture.Events, seattlefoodtruck.Event{ID: e + 1, EventID: e + 1, StartTime: start, EndTime: end})
+		}
+		fixture.Events[e].Bookings = append(fixture.Events[e].Bookings, seattlefoodtruck.Booking{
+			ID: line, Status: seattlefoodtruck.BookingApproved, Truck: seattlefoodtruck.FoodTruck{Name: truck.Name, ID: truck.ID, UID: truck.UID}})
+		fixture.Events[e].Location.UID = location.UID
+	}
+
+	//locations and trucks can be filled in by later rows, copy them into events once all are read
+	byUID := make(map[int]seattlefoodtruck.Location)
+	for _, l := range fixture.Locations {
+		byUID[l.UID] = l
+	}
+	for i := range fixture.Events {
+		e := &fixture.Events[i]
+		e.Location = byUID[e.Location.UID]
+		for j := range e.Bookings {
+			t := fixture.Trucks[trucks[e.Bookings[j].Truck.ID]]
+			e.Bookings[j].Truck.FoodCategories, e.Bookings[j].Truck.FeaturedPhoto = t.FoodCategories, t.FeaturedPhoto
+		}
+	}
+	return fixture, nil
+}
+
+//parseTimes reads the start and end of an event on date in seattlefoodtruck.Timezone
+func parseTimes(date string, start string, end string) (time.Time, time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, seattlefoodtruck.Timezone())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q", date)
+	}
+	clock := func(value string) (time.Time, error) {
+		for _, layout := range []string{"15:04", time.Kitchen, "3:04 PM"} {
+			if t, err := time.Parse(layout, strings.ToUpper(value)); err == nil {
+				return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, seattlefoodtruck.Timezone()), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	st, err := clock(start)
+	if err != nil {
+		return st, st, err
+	}
+	et, err := clock(end)
+	if err != nil {
+		return st, et, err
+	}
+	if !et.After(st) {
+		return st, et, fmt.Errorf("end %s is not after start %s", end, start)
+	}
+	return st, et, nil
+}
+
+//splitCategories splits a semicolon separated list of food categories
+func splitCategories(value string) []string {
+	var categories []string
+	for _, c := range strings.Split(value, ";") {
+		if c = strings.TrimSpace(c); len(c) != 0 {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
+//slug turns a name into an id like the ones seattlefoodtruck.com uses, e.g. Amazon Day 1 becomes
+//amazon-day-1
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+const schedule = `date,start,end,neighborhood,location,address,latitude,longitude,truck,categories,photo
+2018-05-01,11:00,14:00,Pioneer Square,Occidental Park,117 S Washington St,47.6005,-122.3333,Marination,Hawaiian;Korean,marination.jpg
+2018-05-01,11:00,14:00,Pioneer Square,Occidental Park,,,,Nosh,British,
+2018-05-02,5:00PM,8:00PM,Capitol Hill,Cal Anderson Park,1635 11th Ave,,,Marination,,
+`
+
+func TestParseCSV(t *testing.T) {
+	f, err := ParseCSV(strings.NewReader(schedule))
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(f.Neighborhoods) != 2 || f.Neighborhoods[0].ID != "pioneer-square" {
+		t.Errorf("Expected 2 neighborhoods got %+v", f.Neighborhoods)
+	}
+	if len(f.Locations) != 2 || f.Locations[0].ID != "occidental-park" || f.Locations[0].Latitude != 47.6005 {
+		t.Errorf("Expected 2 locations got %+v", f.Locations)
+	}
+	if len(f.Trucks) != 2 || f.Trucks[0].ID != "marination" || f.Trucks[0].FeaturedPhoto != "marination.jpg" {
+		t.Errorf("Expected 2 trucks got %+v", f.Trucks)
+	}
+	if len(f.Events) != 2 {
+		t.Fatalf("Expected rows at the same place and time to be one event got %d events", len(f.Events))
+	}
+	lunch := f.Events[0]
+	if len(lunch.Bookings) != 2 || lunch.Location.Address != "117 S Washington St" {
+		t.Errorf("Expected two bookings at Occidental Park got %+v", lunch)
+	}
+	if got := lunch.Bookings[0].Truck.FoodCategories; len(got) != 2 || got[1] != "Korean" {
+		t.Errorf("Expected the booking to carry the truck's categories got %v", got)
+	}
+	if st := f.Events[1].LocalStart(); st.Hour() != 17 || st.Day() != 2 {
+		t.Errorf("Expected the evening event at 5pm on the 2nd got %v", st)
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	cases := []struct {
+		csv  string
+		want string
+	}{
+		{"", "Missing header row"},
+		{"date,start,end,neighborhood,location", `Missing column "truck"`},
+		{"date,start,end,neighborhood,location,truck,cuisine", `Unknown column "cuisine"`},
+		{"date,start,end,neighborhood,location,truck\n2018-05-01,11:00,14:00,Pioneer Square,,Nosh", "Line 2: missing location"},
+		{"date,start,end,neighborhood,location,truck\n05/01/2018,11:00,14:00,Pioneer Square,Park,Nosh", "invalid date"},
+		{"date,start,end,neighborhood,location,truck\n2018-05-01,14:00,11:00,Pioneer Square,Park,Nosh", "is not after start"},
+	}
+	for _, c := range cases {
+		_, err := ParseCSV(strings.NewReader(c.csv))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%q: expected error '%v' got '%v'", c.csv, c.want, err)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.csv")
+	if err := os.WriteFile(path, []byte(schedule), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	f.PhotoBase = "https://example.com/photos/"
+	ctx := context.Background()
+
+	locations, err := f.Locations(ctx, "capitol-hill")
+	if err != nil || len(locations) != 1 || locations[0].Name != "Cal Anderson Park" {
+		t.Errorf("Expected Cal Anderson Park got %v, %v", locations, err)
+	}
+	day := time.Date(2018, 5, 1, 0, 0, 0, 0, seattlefoodtruck.Timezone())
+	events, err := f.Events(ctx, seattlefoodtruck.NewEventsQuery().ForTrucks("marination").On(day))
+	if err != nil || len(events) != 1 || events[0].Location.Name != "Occidental Park" {
+		t.Errorf("Expected one event for marination on May 1st got %v, %v", events, err)
+	}
+	truck, err := f.Truck(ctx, "Nosh")
+	if err != nil || truck.Name != "Nosh" {
+		t.Errorf("Expected Nosh got %v, %v", truck, err)
+	}
+	if _, err := f.Truck(ctx, "unknown"); !errors.Is(err, seattlefoodtruck.ErrNotFound) {
+		t.Errorf("Expected not found got '%v'", err)
+	}
+	if got := f.PhotoURL("marination.jpg"); got != "https://example.com/photos/marination.jpg" {
+		t.Errorf("Expected photo under PhotoBase got %v", got)
+	}
+}
+
+func TestFileFromJSONAddsBookedTrucks(t *testing.T) {
+	f, err := NewFile(filepath.Join("..", "seattlefoodtruck", "emulator", "fixture.json"))
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	trucks, err := f.Trucks(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	seen := make(map[string]bool)
+	for _, truck := range trucks {
+		if seen[truck.ID] {
+			t.Errorf("Expected %s once", truck.ID)
+		}
+		seen[truck.ID] = true
+	}
+	events, _ := f.Events(context.Background(), seattlefoodtruck.NewEventsQuery())
+	for _, e := range events {
+		for _, b := range e.Bookings {
+			if !seen[b.Truck.ID] {
+				t.Errorf("Expected booked truck %s among the trucks", b.Truck.ID)
+			}
+		}
+	}
+}
+
+func TestFileSwap(t *testing.T) {
+	before, err := ParseCSV(strings.NewReader(schedule))
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	after, err := ParseCSV(strings.NewReader("date,start,end,neighborhood,location,truck\n2018-05-03,11:00,14:00,Ballard,Ballard Commons,Nosh\n"))
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	f := NewFileFromFixture(before)
+	f.Swap(after)
+	neighborhoods, _ := f.Neighborhoods(context.Background())
+	if len(neighborhoods) != 1 || neighborhoods[0].Name != "Ballard" {
+		t.Errorf("Expected the swapped neighborhoods got %+v", neighborhoods)
+	}
+	if _, err := f.Truck(context.Background(), "marination"); !errors.Is(err, seattlefoodtruck.ErrNotFound) {
+		t.Errorf("Expected trucks of the old schedule gone got '%v'", err)
+	}
+	if _, err := f.Truck(context.Background(), "nosh"); err != nil {
+		t.Errorf("Expected booked trucks of the new schedule got '%v'", err)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	for name, want := range map[string]string{
+		"Amazon Day 1":      "amazon-day-1",
+		"  Pike & Pine  ":   "pike-pine",
+		"Café Racer":        "café-racer",
+		"T-Mobile Factoria": "t-mobile-factoria",
+	} {
+		if got := slug(name); got != want {
+			t.Errorf("%q: expected %v got %v", name, want, got)
+		}
+	}
+}
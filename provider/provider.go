@@ -0,0 +1,32 @@
+//Package provider abstracts where the bot gets food truck schedules from so offices in cities
+//without seattlefoodtruck.com can use it. Data is shaped like the seattlefoodtruck api types
+package provider
+
+import (
+	"context"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//Provider is a source of neighborhoods, locations, trucks and their schedules. Lists that could
+//only be partly fetched are returned along with seattlefoodtruck.ErrPageLimit
+type Provider interface {
+	//Neighborhoods returns every neighborhood or area served
+	Neighborhoods(ctx context.Context) ([]seattlefoodtruck.Neighborhood, error)
+	//Locations returns the locations in the neighborhood with the given id, e.g. bellevue
+	Locations(ctx context.Context, neighborhood string) ([]seattlefoodtruck.Location, error)
+	//Events returns the events matching query
+	Events(ctx context.Context, query seattlefoodtruck.EventsQuery) ([]seattlefoodtruck.Event, error)
+	//Trucks returns every known truck
+	Trucks(ctx context.Context) ([]seattlefoodtruck.Truck, error)
+	//Truck returns the profile of the truck with the given id, errors match
+	//seattlefoodtruck.ErrNotFound when there is no such truck
+	Truck(ctx context.Context, id string) (seattlefoodtruck.Truck, error)
+	//PhotoURL turns a photo path from the provider's data into a link
+	PhotoURL(path string) string
+}
+
+//CacheReporter is implemented by providers that cache responses
+type CacheReporter interface {
+	CacheStats() seattlefoodtruck.CacheStats
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//PhotoBucket is where seattlefoodtruck.com keeps truck photos, the api returns paths within it
+const PhotoBucket = "https://s3-us-west-2.amazonaws.com/seattlefoodtruck-uploads-prod/"
+
+//Seattle provides schedules from seattlefoodtruck.com through a seattlefoodtruck.Client
+type Seattle struct {
+	client seattlefoodtruck.Client
+}
+
+var (
+	_ Provider      = (*Seattle)(nil)
+	_ CacheReporter = (*Seattle)(nil)
+)
+
+//NewSeattle creates a provider answering from client
+func NewSeattle(client seattlefoodtruck.Client) *Seattle {
+	return &Seattle{client: client}
+}
+
+//Neighborhoods returns every neighborhood on seattlefoodtruck.com
+func (s *Seattle) Neighborhoods(ctx context.Context) ([]seattlefoodtruck.Neighborhood, error) {
+	return s.client.GetAllNeighborhoodsContext(ctx)
+}
+
+//Locations returns the locations in a neighborhood
+func (s *Seattle) Locations(ctx context.Context, neighborhood string) ([]seattlefoodtruck.Location, error) {
+	lr := seattlefoodtruck.LocationRequest{Page: 1, Neighborhood: neighborhood}
+	return s.client.GetAllLocationsContext(ctx, &lr)
+}
+
+//Events returns the events matching query
+func (s *Seattle) Events(ctx context.Context, query seattlefoodtruck.EventsQuery) ([]seattlefoodtruck.Event, error) {
+	return s.client.GetAllEventsContext(ctx, query)
+}
+
+//Trucks returns every truck on seattlefoodtruck.com
+func (s *Seattle) Trucks(ctx context.Context) ([]seattlefoodtruck.Truck, error) {
+	return s.client.GetAllTrucksContext(ctx)
+}
+
+//Truck returns the profile of a truck
+func (s *Seattle) Truck(ctx context.Context, id string) (seattlefoodtruck.Truck, error) {
+	return s.client.GetTruckContext(ctx, id)
+}
+
+//PhotoURL links a photo path returned by the api to the photo bucket
+func (s *Seattle) PhotoURL(path string) string {
+	return photoURL(PhotoBucket, path)
+}
+
+//CacheStats reports how the client's cache has been doing
+func (s *Seattle) CacheStats() seattlefoodtruck.CacheStats {
+	return s.client.CacheStats()
+}
+
+//photoURL joins path to base unless it is empty or already a link
+func photoURL(base string, path string) string {
+	if len(path) == 0 || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return base + path
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestSeattle(t *testing.T) {
+	fixture := seattlefoodtruck.Fixture{
+		Neighborhoods: []seattlefoodtruck.Neighborhood{{Name: "Bellevue", ID: "bellevue", UID: 4}},
+		Locations:     []seattlefoodtruck.Location{{Name: "T-Mobile Factoria", UID: 44, NeighborhoodID: 4}},
+		Trucks:        []seattlefoodtruck.Truck{{Name: "Marination", ID: "marination"}},
+	}
+	s := NewSeattle(seattlefoodtruck.NewFakeClient(fixture))
+	ctx := context.Background()
+
+	if n, err := s.Neighborhoods(ctx); err != nil || len(n) != 1 {
+		t.Errorf("Expected one neighborhood got %v, %v", n, err)
+	}
+	if l, err := s.Locations(ctx, "bellevue"); err != nil || len(l) != 1 || l[0].UID != 44 {
+		t.Errorf("Expected location 44 got %v, %v", l, err)
+	}
+	if truck, err := s.Truck(ctx, "marination"); err != nil || truck.Name != "Marination" {
+		t.Errorf("Expected Marination got %v, %v", truck, err)
+	}
+}
+
+func TestPhotoURL(t *testing.T) {
+	s := NewSeattle(seattlefoodtruck.NewFakeClient(seattlefoodtruck.Fixture{}))
+	cases := map[string]string{
+		"trucks/marination.jpg":        PhotoBucket + "trucks/marination.jpg",
+		"https://example.com/nosh.jpg": "https://example.com/nosh.jpg",
+		"":                             "",
+	}
+	for path, want := range cases {
+		if got := s.PhotoURL(path); got != want {
+			t.Errorf("%q: expected %v got %v", path, want, got)
+		}
+	}
+}
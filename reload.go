@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+//digestRunner runs the cron jobs of the configured digests and swaps them for new ones when the
+//configuration is reloaded. It is safe for concurrent use
+type digestRunner struct {
+	ctx context.Context
+	bot *bot
+	//reloading serializes reloads from the watcher and SIGHUP
+	reloading sync.Mutex
+
+	mu   sync.Mutex
+	cfg  *config
+	cron *cron.Cron
+	//pollers are kept across reloads by digest name so alerts stay threaded under today's digest
+	pollers map[string]*changePoller
+}
+
+//newDigestRunner creates a runner posting digests answered by b until ctx is done
+func newDigestRunner(ctx context.Context, b *bot) *digestRunner {
+	return &digestRunner{ctx: ctx, bot: b, cfg: &config{}}
+}
+
+//apply replaces the running digests with those of cfg, cfg must be valid
+func (r *digestRunner) apply(cfg *config) {
+	r.mu.Lock()
+	r.swap(cfg)
+	running := r.cron != nil
+	r.mu.Unlock()
+
+	if running {
+		status.setCron(r.entries)
+	} else {
+		status.setCron(nil)
+	}
+}
+
+//swap stops the running cron and starts one with the jobs of cfg, r.mu must be held
+func (r *digestRunner) swap(cfg *config) {
+	var c *cron.Cron
+	pollers := make(map[string]*changePoller)
+	if len(cfg.Digests) > 0 {
+		c = cron.New()
+		for _, d := range cfg.Digests {
+			poller := r.pollers[d.Name]
+			if old, ok := findDigest(r.cfg.Digests, d.Name); !ok || old.Channel != d.Channel || !sameLocations(old.Locations, d.Locations) {
+				poller = newChangePoller(r.bot, d.Locations)
+			}
+			pollers[d.Name] = poller
+			scheduleDigest(r.ctx, c, r.bot, d, poller)
+			slog.Info("Scheduled digest", "digest", d.Name, "channel", d.Channel, "locations", d.Locations, "schedule", d.Schedule)
+		}
+	}
+	if r.cron != nil {
+		r.cron.Stop()
+	}
+	r.cfg, r.cron, r.pollers = cfg, c, pollers
+	if c != nil {
+		slog.Info("Starting cron", "digests", len(cfg.Digests), "poll_interval", pollInterval)
+		c.Start()
+	}
+}
+
+//reload loads the configuration at path, or from the environment when path is empty, and applies
+//it when it changed. An invalid configuration is rejected and the running one kept
+func (r *digestRunner) reload(path string) error {
+	//held while loading too, or a reload that read an older file could apply after a newer one
+	r.reloading.Lock()
+	defer r.reloading.Unlock()
+	cfg, err := loadConfig(path)
+	if err != nil {
+		slog.Error("Rejected configuration, keeping the running one", "error", err)
+		return err
+	}
+	r.mu.Lock()
+	changes := diffConfigs(r.cfg, cfg)
+	r.mu.Unlock()
+	if len(changes) == 0 {
+		slog.Info("Reloaded configuration, nothing changed")
+		return nil
+	}
+	for _, change := range changes {
+		slog.Info("Configuration changed", "change", change)
+	}
+	r.apply(cfg)
+	return nil
+}
+
+//entries counts the scheduled jobs
+func (r *digestRunner) entries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cron == nil {
+		return 0
+	}
+	return len(r.cron.Entries())
+}
+
+//stop stops the running digests, jobs already running finish
+func (r *digestRunner) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cron != nil {
+		r.cron.Stop()
+	}
+}
+
+//diffConfigs describes what changed from before to after, digests are matched by name
+func diffConfigs(before, after *config) []string {
+	var changes []string
+	if before.SlackToken != after.SlackToken {
+		changes = append(changes, "Slack token changed, restart the bot to use it")
+	}
+	names := make(map[string]bool)
+	for _, d := range after.Digests {
+		names[d.Name] = true
+		old, ok := findDigest(before.Digests, d.Name)
+		if !ok {
+			changes = append(changes, fmt.Sprintf("Added digest %s posting to %s", d.Name, d.Channel))
+			continue
+		}
+		changes = append(changes, digestChanges(old, d)...)
+	}
+	for _, d := range before.Digests {
+		if !names[d.Name] {
+			changes = append(changes, fmt.Sprintf("Removed digest %s", d.Name))
+		}
+	}
+	return changes
+}
+
+//digestChanges describes the settings of a digest that changed from before to after
+func digestChanges(before, after digestConfig) []string {
+	fields := []struct{ name, before, after string }{
+		{"channel", before.Channel, after.Channel},
+		{"locations", strings.Join(before.Locations, ", "), strings.Join(after.Locations, ", ")},
+		{"schedule", before.Schedule, after.Schedule},
+		{"timezone", before.Timezone, after.Timezone},
+		{"title", before.Title, after.Title},
+		{"sort_by_distance", optionalBool(before.SortByDistance), optionalBool(after.SortByDistance)},
+		{"change_alerts", optionalBool(before.ChangeAlerts), optionalBool(after.ChangeAlerts)},
+	}
+	var changes []string
+	for _, f := range fields {
+		if f.before != f.after {
+			changes = append(changes, fmt.Sprintf("Digest %s: %s changed from %q to %q", after.Name, f.name, f.before, f.after))
+		}
+	}
+	return changes
+}
+
+//findDigest returns the digest called name
+func findDigest(digests []digestConfig, name string) (digestConfig, bool) {
+	for _, d := range digests {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return digestConfig{}, false
+}
+
+//sameLocations says whether a and b list the same locations in the same order
+func sameLocations(a, b []string) bool {
+	return strings.Join(a, "\x00") == strings.Join(b, "\x00")
+}
+
+//optionalBool formats an optional setting, unset ones are empty
+func optionalBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+//watchConfig calls reload when the file at path changes, checking every interval until ctx is done
+func watchConfig(ctx context.Context, path string, interval time.Duration, reload func()) {
+	last, err := os.Stat(path)
+	if err != nil {
+		slog.Warn("Cannot watch configuration", "path", path, "error", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			if last != nil {
+				slog.Warn("Configuration file is gone, keeping the running one", "path", path, "error", err)
+			}
+			last = nil
+			continue
+		}
+		if last == nil || !info.ModTime().Equal(last.ModTime()) || info.Size() != last.Size() {
+			slog.Info("Configuration file changed", "path", path)
+			last = info
+			reload()
+		}
+	}
+}
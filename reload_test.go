@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	on := true
+	before := &config{SlackToken: "a", Digests: []digestConfig{
+		{Name: "downtown", Channel: "C1", Locations: []string{"44"}, Schedule: defaultSchedule},
+		{Name: "south", Channel: "C2", Locations: []string{"45"}, Schedule: defaultSchedule},
+	}}
+	after := &config{SlackToken: "a", Digests: []digestConfig{
+		{Name: "downtown", Channel: "C3", Locations: []string{"44", "46"}, Schedule: defaultSchedule, SortByDistance: &on},
+		{Name: "north", Channel: "C4", Locations: []string{"47"}, Schedule: defaultSchedule},
+	}}
+	want := []string{
+		`Digest downtown: channel changed from "C1" to "C3"`,
+		`Digest downtown: locations changed from "44" to "44, 46"`,
+		`Digest downtown: sort_by_distance changed from "" to "true"`,
+		"Added digest north posting to C4",
+		"Removed digest south",
+	}
+	if got := diffConfigs(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q got %q", want, got)
+	}
+	if got := diffConfigs(after, after); len(got) != 0 {
+		t.Errorf("Expected no changes got %q", got)
+	}
+}
+
+func TestDigestRunnerReload(t *testing.T) {
+	defer func(saved string) { token = saved }(token)
+	token = "secret"
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("digests:\n  - channel: C1\n    locations: [44]\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := newDigestRunner(ctx, newBot(provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture()))))
+	defer r.stop()
+	if err := r.reload(path); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if n := r.entries(); n != 2 {
+		t.Errorf("Expected the digest and its change alerts scheduled got %v jobs", n)
+	}
+	poller := r.pollers["C1"]
+
+	write("digests:\n  - channel: C1\n    locations: [44]\n    schedule: not a schedule\n")
+	if err := r.reload(path); err == nil {
+		t.Error("Expected an invalid config to be rejected")
+	}
+	if r.cfg.Digests[0].Schedule != defaultSchedule || r.entries() != 2 {
+		t.Errorf("Expected the running config kept got %+v", r.cfg)
+	}
+
+	write("digests:\n  - channel: C1\n    locations: [44]\n    schedule: \"0 0 11 * * *\"\n  - channel: C2\n    locations: [45]\n    change_alerts: false\n")
+	if err := r.reload(path); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if n := r.entries(); n != 3 {
+		t.Errorf("Expected 3 jobs got %v", n)
+	}
+	if r.pollers["C1"] != poller {
+		t.Error("Expected the poller of an unchanged digest to be kept")
+	}
+
+	write("digests: []\n")
+	if err := r.reload(path); err != nil || r.entries() != 0 {
+		t.Errorf("Expected every digest removed got %v jobs, %v", r.entries(), err)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("digests: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloads := make(chan struct{}, 1)
+	go watchConfig(ctx, path, 5*time.Millisecond, func() { reloads <- struct{}{} })
+
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("digests:\n  - channel: C1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a reload after the file changed")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//candidate is an item a free text name may refer to, with the command argument that picks it
+type candidate struct {
+	label string
+	arg   string
+}
+
+//candidatesMessage asks which of candidates name meant, suggesting command to pick one
+func candidatesMessage(name string, command string, candidates []candidate) string {
+	message := fmt.Sprintf("*Which %s did you mean?* \n", name)
+	for _, c := range candidates {
+		message += fmt.Sprintf("• %s - %s %s \n", c.label, command, c.arg)
+	}
+	return message
+}
+
+//resolveNeighborhood finds the neighborhood called name by id or display name. When there is no
+//single match it returns nil and a message to show instead, suggesting command to pick one
+func (b *bot) resolveNeighborhood(ctx context.Context, name string, command string) (*seattlefoodtruck.Neighborhood, string) {
+	neighborhoods, err := b.source.Neighborhoods(ctx)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Searching partial list of neighborhoods", "error", err)
+	} else if err != nil {
+		return nil, errorMessage(ctx, err)
+	}
+	matches := matchNames(name, len(neighborhoods), func(i int) []string {
+		return []string{neighborhoods[i].ID, neighborhoods[i].Name}
+	})
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Sprintf("No neighborhood named %s, try *show neighborhoods*", name)
+	case 1:
+		return &neighborhoods[matches[0]], ""
+	}
+	var candidates []candidate
+	for _, i := range matches {
+		candidates = append(candidates, candidate{neighborhoods[i].Name, neighborhoods[i].ID})
+	}
+	return nil, candidatesMessage(name, command, candidates)
+}
+
+//resolveLocation finds the location called name by uid, slug, display name or address across all
+//neighborhoods. When there is no single match it returns nil and a message to show instead,
+//suggesting command to pick one
+func (b *bot) resolveLocation(ctx context.Context, name string, command string) (*seattlefoodtruck.Location, string) {
+	name = strings.TrimSpace(name)
+	if uid, err := strconv.Atoi(name); err == nil {
+		if uid <= 0 {
+			return nil, fmt.Sprintf("%s is not a valid location", name)
+		}
+		return &seattlefoodtruck.Location{UID: uid}, ""
+	}
+	locations, err := b.allLocations(ctx)
+	if err != nil {
+		return nil, errorMessage(ctx, err)
+	}
+	matches := matchNames(name, len(locations), func(i int) []string {
+		l := locations[i]
+		return []string{l.ID, l.Slug, l.Name, l.FilteredAddress, l.Address}
+	})
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Sprintf("No location named %s, try *show locations in <neighborhood>*", name)
+	case 1:
+		return &locations[matches[0]], ""
+	}
+	var candidates []candidate
+	for _, i := range matches {
+		l := locations[i]
+		candidates = append(candidates, candidate{fmt.Sprintf("%s (%s)", l.Name, l.FilteredAddress), strconv.Itoa(l.UID)})
+	}
+	return nil, candidatesMessage(name, command, candidates)
+}
+
+//allLocations gets the locations in every neighborhood, the provider's cache keeps repeated lookups cheap
+func (b *bot) allLocations(ctx context.Context) ([]seattlefoodtruck.Location, error) {
+	neighborhoods, err := b.source.Neighborhoods(ctx)
+	if err == seattlefoodtruck.ErrPageLimit {
+		logger(ctx).Warn("Searching locations of a partial list of neighborhoods", "error", err)
+	} else if err != nil {
+		return nil, err
+	}
+	var locations []seattlefoodtruck.Location
+	seen := make(map[int]bool)
+	for _, n := range neighborhoods {
+		found, err := b.source.Locations(ctx, n.ID)
+		if err == seattlefoodtruck.ErrPageLimit {
+			logger(ctx).Warn("Searching partial list of locations", "error", err)
+		} else if err != nil {
+			return nil, err
+		}
+		for _, l := range found {
+			if !seen[l.UID] {
+				seen[l.UID] = true
+				locations = append(locations, l)
+			}
+		}
+	}
+	return locations, nil
+}
+
+//argumentAfter returns what follows the first whole word keyword in text, e.g. the location in
+//"show trucks at amazon doppler". ok is false when keyword is missing
+func argumentAfter(text string, keyword string) (string, bool) {
+	fields := strings.Fields(text)
+	for i, f := range fields {
+		if f == keyword {
+			return strings.Join(fields[i+1:], " "), true
+		}
+	}
+	return "", false
+}
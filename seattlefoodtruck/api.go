@@ -1,31 +1,44 @@
 package seattlefoodtruck
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
+//Neighborhood is a neighborhood where you can find food trucks
+type Neighborhood struct {
+	Name        string `json:"name"`
+	Latitude    string `json:"latitude"`
+	Longitude   string `json:"longitude"`
+	Description string `json:"description"`
+	ZoomLevel   int    `json:"zoom_level"`
+	Photo       string `json:"photo"`
+	ID          string `json:"id"`
+	UID         int    `json:"uid"`
+}
+
 //NeighborhoodResponse neighborhood api response
 type NeighborhoodResponse struct {
-	Pagination struct {
-		Page       int `json:"page"`
-		TotalPages int `json:"total_pages"`
-		TotalCount int `json:"total_count"`
-	} `json:"pagination"`
-	Neighborhoods []struct {
-		Name        string `json:"name"`
-		Latitude    string `json:"latitude"`
-		Longitude   string `json:"longitude"`
-		Description string `json:"description"`
-		ZoomLevel   int    `json:"zoom_level"`
-		Photo       string `json:"photo"`
-		ID          string `json:"id"`
-		UID         int    `json:"uid"`
-	} `json:"neighborhoods"`
+	Pagination    Pagination     `json:"pagination"`
+	Neighborhoods []Neighborhood `json:"neighborhoods"`
+}
+
+//NeighborhoodRequest request to get a page of neighborhoods
+type NeighborhoodRequest struct {
+	Page int
+}
+
+func (nr NeighborhoodRequest) toQueryString() string {
+	if nr.Page <= 1 {
+		return ""
+	}
+	return "?page=" + strconv.Itoa(nr.Page)
 }
 
 //LocationRequest request to get locations
@@ -34,12 +47,18 @@ type LocationRequest struct {
 	Neighborhood string
 }
 
-func (lr LocationRequest) toQueryString() string {
-	var qs = "?"
-
+//withDefaults fills in the fields the caller left empty
+func (lr LocationRequest) withDefaults() LocationRequest {
 	if len(lr.Neighborhood) == 0 {
 		lr.Neighborhood = "bellevue" //set default to bellevue
 	}
+	return lr
+}
+
+func (lr LocationRequest) toQueryString() string {
+	var qs = "?"
+
+	lr = lr.withDefaults()
 	qs += "page=" + strconv.Itoa(lr.Page) + "&only_with_events=true" + "&neighborhood=" + lr.Neighborhood +
 		"&with_active_trucks=true"
 
@@ -48,42 +67,33 @@ func (lr LocationRequest) toQueryString() string {
 
 //LocationResponse Get locations response
 type LocationResponse struct {
-	Pagination struct {
-		Page       int `json:"page"`
-		TotalPages int `json:"total_pages"`
-		TotalCount int `json:"total_count"`
-	} `json:"pagination"`
-	Locations []struct {
-		Name            string  `json:"name"`
-		Longitude       float64 `json:"longitude"`
-		Latitude        float64 `json:"latitude"`
-		Address         string  `json:"address"`
-		Photo           string  `json:"photo"`
-		GooglePlaceID   string  `json:"google_place_id"`
-		CreatedAt       string  `json:"created_at"`
-		NeighborhoodID  int     `json:"neighborhood_id"`
-		Slug            string  `json:"slug"`
-		FilteredAddress string  `json:"filtered_address"`
-		ID              string  `json:"id"`
-		UID             int     `json:"uid"`
-		Neighborhood    struct {
-			Name string `json:"name"`
-			ID   int    `json:"id"`
-		} `json:"neighborhood"`
-		Pod struct {
-			Name        string `json:"name"`
-			Description string `json:"description"`
-		} `json:"pod,omitempty"`
-	} `json:"locations"`
-}
-
-//Location is a location where you can find truck
+	Pagination Pagination `json:"pagination"`
+	Locations  []Location `json:"locations"`
+}
+
+//Location is a location where you can find truck. Locations embedded in events
+//only carry the name, address and ids
 type Location struct {
-	Name            string `json:"name"`
-	Address         string `json:"address"`
-	FilteredAddress string `json:"filtered_address"`
-	ID              string `json:"id"`
-	UID             int    `json:"uid"`
+	Name            string  `json:"name"`
+	Longitude       float64 `json:"longitude,omitempty"`
+	Latitude        float64 `json:"latitude,omitempty"`
+	Address         string  `json:"address"`
+	Photo           string  `json:"photo,omitempty"`
+	GooglePlaceID   string  `json:"google_place_id,omitempty"`
+	CreatedAt       string  `json:"created_at,omitempty"`
+	NeighborhoodID  int     `json:"neighborhood_id,omitempty"`
+	Slug            string  `json:"slug,omitempty"`
+	FilteredAddress string  `json:"filtered_address"`
+	ID              string  `json:"id"`
+	UID             int     `json:"uid"`
+	Neighborhood    struct {
+		Name string `json:"name"`
+		ID   int    `json:"id"`
+	} `json:"neighborhood"`
+	Pod struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"pod,omitempty"`
 }
 
 //LocationEventsRequest is request for seattle food trucks API at a location
@@ -118,15 +128,16 @@ type Booking struct {
 	Truck  FoodTruck `json:"truck"`
 }
 
-//Event Event at a location
+//Event Event at a location. Times keep the offset the api sent, use LocalStart and LocalEnd to show
+//them in the reference timezone
 type Event struct {
 	ID          int       `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	StartTime   string    `json:"start_time"`
-	EndTime     string    `json:"end_time"`
-	CreatedAt   string    `json:"created_at"`
-	UpdatedAt   string    `json:"updated_at"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 	EventID     int       `json:"event_id"`
 	Bookings    []Booking `json:"bookings"`
 	Location    Location  `json:"location"`
@@ -145,24 +156,55 @@ type LocationEventsResponse struct {
 	Events []Event    `json:"events"`
 }
 
-func (ler LocationEventsRequest) toQueryString() string {
-	var qs = "?"
+//withDefaults fills in the fields the caller left empty
+func (ler LocationEventsRequest) withDefaults() LocationEventsRequest {
 	if ler.Location == 0 {
 		ler.Location = 44 //set it to T-Mobile factoria location as default
 	}
 	if ler.Page == 0 {
 		ler.Page = 1
 	}
-	qs += "page=" + strconv.Itoa(ler.Page) + "&for_locations=" + strconv.Itoa(ler.Location) + "&with_active_trucks=true" +
-		"&include_bookings=true" + "&include_locations=true" + "&with_booking_status=approved"
+	return ler
+}
+
+//Query returns the events query the request stands for: approved bookings with trucks at the
+//location, including bookings and locations
+func (ler LocationEventsRequest) Query() EventsQuery {
+	ler = ler.withDefaults()
+	return NewEventsQuery().ForLocations(ler.Location).Page(ler.Page).WithActiveTrucks(true).
+		WithBookingStatus(BookingApproved)
+}
 
-	return qs
+func (ler LocationEventsRequest) toQueryString() string {
+	return ler.Query().toQueryString()
 }
 
+//DefaultTimeout is how long a request waits for the api when the caller did not set a deadline
+const DefaultTimeout = 30 * time.Second
+
 //Proxy is a Seattle Food Truck API proxy
 type Proxy struct {
 	HTTPClient *http.Client
 	BaseURL    string
+	//MaxPages caps how many pages the Walk and GetAll methods will fetch, zero means DefaultMaxPages
+	MaxPages int
+	//Timeout is applied to each attempt of a call whose context has no deadline, zero means
+	//DefaultTimeout and a negative value disables it
+	Timeout time.Duration
+	//Retry controls how failed requests are retried, the zero value disables retries
+	Retry RetryPolicy
+	//Cache stores responses keyed by endpoint and query string, nil disables caching
+	Cache Cache
+	//CacheTTL is how long responses are cached per endpoint, endpoints without a TTL are not cached
+	CacheTTL map[string]time.Duration
+	//RateLimit spaces out requests to the api including retries, nil disables it
+	RateLimit *RateLimiter
+	//OnRequest is called after every request sent to the api, including retries, with the context of
+	//the call that made it, without the proxy timeout
+	OnRequest func(context.Context, RequestEvent)
+
+	counters *cacheCounters
+	flights  *flightGroup
 }
 
 //NewProxy creates a new proxy
@@ -172,89 +214,252 @@ func NewProxy(baseURL string) (Proxy, error) {
 	if len(baseURL) == 0 {
 		return p, fmt.Errorf("Invalid Parameter: baseURL is missing")
 	}
+	ttls := make(map[string]time.Duration, len(DefaultCacheTTLs))
+	for endpoint, ttl := range DefaultCacheTTLs {
+		ttls[endpoint] = ttl
+	}
 	p = Proxy{
-		HTTPClient: http.DefaultClient,
+		HTTPClient: &http.Client{},
 		BaseURL:    baseURL,
+		MaxPages:   DefaultMaxPages,
+		Timeout:    DefaultTimeout,
+		Retry:      DefaultRetryPolicy,
+		Cache:      NewMemoryCache(DefaultCacheEntries),
+		CacheTTL:   ttls,
+		RateLimit:  NewRateLimiter(DefaultRequestsPerSecond, DefaultRequestsPerSecond),
+		counters:   &cacheCounters{},
+		flights:    &flightGroup{},
 	}
 	return p, nil
 }
 
-//GetLocationEvents gets events for a specific location
-func (p Proxy) GetLocationEvents(request *LocationEventsRequest) (LocationEventsResponse, error) {
-	var r LocationEventsResponse
-	var api = "/api/events"
+//RequestEvent describes a request the proxy sent to the api
+type RequestEvent struct {
+	Endpoint string
+	//Route is Endpoint with ids replaced by placeholders, e.g. /api/trucks/{id}, so it can label
+	//metrics without a series per id
+	Route string
+	//StatusCode is the http status of the response, zero when no response was received
+	StatusCode int
+	//Duration is how long the api took to answer, not counting time waiting for the rate limit
+	Duration time.Duration
+	//Err is set when the request failed
+	Err error
+}
 
-	if request == nil {
-		return r, fmt.Errorf("Invalid Request")
+//withTimeout applies the proxy timeout to ctx unless ctx already carries a deadline
+func (p Proxy) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || p.Timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
 	}
-	//convert the request into a querystring and concatenate to API string
-	qs := request.toQueryString()
-	api += qs
+	return context.WithTimeout(ctx, timeout)
+}
 
-	httpRequest, err := http.NewRequest("GET", p.BaseURL+api, nil)
-	if err != nil {
-		return r, fmt.Errorf("An error occured creating http request")
+//get executes a GET request for endpoint with query string qs and decodes the json response into v,
+//retrying according to the proxy retry policy. Identical requests in flight are coalesced into
+//one. Failures are reported as *APIError
+func (p Proxy) get(ctx context.Context, endpoint string, qs string, v interface{}) error {
+	key := endpoint + qs
+	ttl := p.cacheTTL(endpoint)
+	var cached *CacheEntry
+	if ttl > 0 {
+		if e, ok := p.Cache.Get(key); ok {
+			if e.Fresh(time.Now()) {
+				if err := json.Unmarshal(e.Body, v); err == nil {
+					p.counters.hit()
+					return nil
+				}
+			}
+			cached = &e
+		}
 	}
-	//Execute the request
-	httpResponse, err := p.HTTPClient.Do(httpRequest)
+	entry, shared, err := p.flights.do(ctx, key, func(ctx context.Context) (CacheEntry, error) {
+		return p.fetch(ctx, endpoint, qs, cached, ttl)
+	})
+	if shared {
+		p.counters.coalesce()
+	}
+	url := p.BaseURL + key
 	if err != nil {
-		return r, fmt.Errorf("An Error occurred querying location events using seattle food trucks api")
+		if _, ok := err.(*APIError); !ok {
+			err = &APIError{Endpoint: endpoint, URL: url, Err: err}
+		}
+		return err
 	}
-	//Response body must be closed
-	defer httpResponse.Body.Close()
-	if err := json.NewDecoder(httpResponse.Body).Decode(&r); err != nil {
-		log.Println(err)
-		return r, fmt.Errorf(err.Error())
+	if err := json.Unmarshal(entry.Body, v); err != nil {
+		return &APIError{Endpoint: endpoint, StatusCode: http.StatusOK, URL: url, Body: truncateBody(entry.Body), Err: err}
 	}
-	return r, nil
+	return nil
 }
 
-//GetNeighborhoods gets seattle neighborhoods where you can find food trucks
-func (p Proxy) GetNeighborhoods() (NeighborhoodResponse, error) {
-	var nr NeighborhoodResponse
-	var api = "/api/neighborhoods"
+//fetch gets a response from the api, retrying according to the proxy retry policy, and caches it
+//for ttl
+func (p Proxy) fetch(ctx context.Context, endpoint string, qs string, cached *CacheEntry, ttl time.Duration) (CacheEntry, error) {
+	for attempt := 1; ; attempt++ {
+		var raw json.RawMessage
+		entry, err := p.do(ctx, endpoint, qs, cached, &raw)
+		if err == nil {
+			if ttl > 0 {
+				entry.Expires = time.Now().Add(ttl)
+				p.Cache.Set(endpoint+qs, entry)
+			}
+			return entry, nil
+		}
+		delay, retry := p.Retry.next(ctx, attempt, err)
+		if !retry {
+			return entry, err
+		}
+		if p.Retry.OnRetry != nil {
+			p.Retry.OnRetry(RetryEvent{Ctx: ctx, Endpoint: endpoint, URL: p.BaseURL + endpoint + qs, Attempt: attempt, Delay: delay, Err: err})
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return entry, err
+		case <-timer.C:
+		}
+	}
+}
+
+//do executes a single attempt of a GET request. When cached is set the request is made conditional
+//and a 304 Not Modified response is answered from it. The returned entry holds the response body
+//and validators so the caller can cache it
+func (p Proxy) do(callCtx context.Context, endpoint string, qs string, cached *CacheEntry, v interface{}) (entry CacheEntry, err error) {
+	ctx, cancel := p.withTimeout(callCtx)
+	defer cancel()
 
-	httpRequest, err := http.NewRequest("GET", p.BaseURL+api, nil)
+	url := p.BaseURL + endpoint + qs
+	if err := p.RateLimit.Wait(ctx); err != nil {
+		return entry, &APIError{Endpoint: endpoint, URL: url, Err: err}
+	}
+	start := time.Now()
+	status := 0
+	if p.OnRequest != nil {
+		defer func() {
+			p.OnRequest(callCtx, RequestEvent{Endpoint: endpoint, Route: route(endpoint), StatusCode: status, Duration: time.Since(start), Err: err})
+		}()
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nr, fmt.Errorf("An error occurred creating http request")
+		return entry, &APIError{Endpoint: endpoint, URL: url, Err: err}
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			httpRequest.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			httpRequest.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
 	}
 	//Execute the request
-	httpResponse, err := p.HTTPClient.Do(httpRequest)
+	httpResponse, err := client.Do(httpRequest)
 	if err != nil {
-		return nr, fmt.Errorf("An error occurred querying neighborhoods using seattle food trucks api")
+		//let callers tell a cancelled or timed out call apart from an api failure
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return entry, &APIError{Endpoint: endpoint, URL: url, Err: err}
 	}
+	status = httpResponse.StatusCode
 	//Response body must be closed
 	defer httpResponse.Body.Close()
-	if err := json.NewDecoder(httpResponse.Body).Decode(&nr); err != nil {
-		return nr, fmt.Errorf(err.Error())
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return entry, &APIError{Endpoint: endpoint, StatusCode: httpResponse.StatusCode, URL: url, Err: err}
+	}
+	if httpResponse.StatusCode == http.StatusNotModified && cached != nil {
+		entry = *cached
+		if err := json.Unmarshal(entry.Body, v); err != nil {
+			return entry, &APIError{Endpoint: endpoint, StatusCode: httpResponse.StatusCode, URL: url, Body: truncateBody(entry.Body), Err: err}
+		}
+		p.counters.revalidated()
+		return entry, nil
+	}
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return entry, &APIError{
+			Endpoint:   endpoint,
+			StatusCode: httpResponse.StatusCode,
+			URL:        url,
+			Body:       truncateBody(body),
+			RetryAfter: parseRetryAfter(httpResponse.Header.Get("Retry-After"), time.Now()),
+		}
 	}
-	return nr, nil
+	if err := json.Unmarshal(body, v); err != nil {
+		return entry, &APIError{Endpoint: endpoint, StatusCode: httpResponse.StatusCode, URL: url, Body: truncateBody(body), Err: err}
+	}
+	p.counters.miss()
+	entry = CacheEntry{
+		Body:         body,
+		ETag:         httpResponse.Header.Get("ETag"),
+		LastModified: httpResponse.Header.Get("Last-Modified"),
+	}
+	return entry, nil
+}
+
+//GetLocationEvents gets events for a specific location
+func (p Proxy) GetLocationEvents(request *LocationEventsRequest) (LocationEventsResponse, error) {
+	return p.GetLocationEventsContext(context.Background(), request)
+}
+
+//GetLocationEventsContext gets events for a specific location with a custom context
+func (p Proxy) GetLocationEventsContext(ctx context.Context, request *LocationEventsRequest) (LocationEventsResponse, error) {
+	var r LocationEventsResponse
+
+	if request == nil {
+		return r, fmt.Errorf("Invalid Request")
+	}
+	//convert the request into a querystring
+	err := p.get(ctx, "/api/events", request.toQueryString(), &r)
+	return r, err
+}
+
+//GetNeighborhoods gets the first page of seattle neighborhoods where you can find food trucks
+func (p Proxy) GetNeighborhoods() (NeighborhoodResponse, error) {
+	return p.GetNeighborhoodsContext(context.Background())
+}
+
+//GetNeighborhoodsContext gets the first page of seattle neighborhoods with a custom context
+func (p Proxy) GetNeighborhoodsContext(ctx context.Context) (NeighborhoodResponse, error) {
+	return p.GetNeighborhoodsPageContext(ctx, &NeighborhoodRequest{Page: 1})
+}
+
+//GetNeighborhoodsPage gets a specific page of seattle neighborhoods where you can find food trucks
+func (p Proxy) GetNeighborhoodsPage(request *NeighborhoodRequest) (NeighborhoodResponse, error) {
+	return p.GetNeighborhoodsPageContext(context.Background(), request)
+}
+
+//GetNeighborhoodsPageContext gets a specific page of seattle neighborhoods with a custom context
+func (p Proxy) GetNeighborhoodsPageContext(ctx context.Context, request *NeighborhoodRequest) (NeighborhoodResponse, error) {
+	var nr NeighborhoodResponse
+
+	if request == nil {
+		return nr, fmt.Errorf("Invalid Request")
+	}
+	err := p.get(ctx, "/api/neighborhoods", request.toQueryString(), &nr)
+	return nr, err
 }
 
 //GetLocations gets all locations at a specific neighborhood in seattle where you can find food trucks
 func (p Proxy) GetLocations(request *LocationRequest) (LocationResponse, error) {
+	return p.GetLocationsContext(context.Background(), request)
+}
+
+//GetLocationsContext gets all locations at a specific neighborhood with a custom context
+func (p Proxy) GetLocationsContext(ctx context.Context, request *LocationRequest) (LocationResponse, error) {
 	var lr LocationResponse
-	var api = "/api/locations"
 
 	if request == nil {
 		return lr, fmt.Errorf("Invalid Request")
 	}
-	qs := request.toQueryString()
-	api += qs
-	httpRequest, err := http.NewRequest("GET", p.BaseURL+api, nil)
-	if err != nil {
-		return lr, fmt.Errorf("An error occured creating http request")
-	}
-	//Execute the request
-	httpResponse, err := p.HTTPClient.Do(httpRequest)
-	if err != nil {
-		return lr, fmt.Errorf("An Error occurred querying location using seattle food trucks api")
-	}
-	//Response body must be closed
-	defer httpResponse.Body.Close()
-	if err := json.NewDecoder(httpResponse.Body).Decode(&lr); err != nil {
-		log.Println(err)
-		return lr, fmt.Errorf(err.Error())
-	}
-	return lr, nil
+	err := p.get(ctx, "/api/locations", request.toQueryString(), &lr)
+	return lr, err
 }
@@ -0,0 +1,176 @@
+package seattlefoodtruck
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//DefaultCacheTTLs are how long responses of each endpoint are served from cache before they are
+//revalidated with the api. Schedules change rarely during a day, neighborhoods almost never
+var DefaultCacheTTLs = map[string]time.Duration{
+	"/api/neighborhoods": 24 * time.Hour,
+	"/api/locations":     6 * time.Hour,
+	"/api/events":        15 * time.Minute,
+	"/api/trucks":        24 * time.Hour,
+}
+
+//DefaultCacheEntries is how many responses a memory cache created by NewProxy holds
+const DefaultCacheEntries = 1000
+
+//Cache stores api responses keyed by endpoint and query string. Implementations must be safe for
+//concurrent use
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+}
+
+//CacheEntry is a cached api response
+type CacheEntry struct {
+	Body []byte
+	//ETag and LastModified are the validators the api sent, used to revalidate a stale entry
+	ETag         string
+	LastModified string
+	//Expires is when the entry needs to be revalidated
+	Expires time.Time
+}
+
+//Fresh reports whether the entry can be served without asking the api
+func (e CacheEntry) Fresh(now time.Time) bool {
+	return now.Before(e.Expires)
+}
+
+//CacheStats counts how requests were answered
+type CacheStats struct {
+	//Hits were answered from a fresh cache entry
+	Hits uint64
+	//Revalidations were answered from a stale cache entry after the api replied 304 Not Modified
+	Revalidations uint64
+	//Misses needed a full response from the api
+	Misses uint64
+	//Coalesced joined an identical request already in flight instead of making their own
+	Coalesced uint64
+}
+
+//HitRatio is the share of requests that did not need a full response from the api
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Revalidations + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits+s.Revalidations) / float64(total)
+}
+
+//cacheCounters is shared by copies of a proxy so stats survive passing the proxy by value
+type cacheCounters struct {
+	hits, revalidations, misses, coalesced uint64
+}
+
+//CacheStats returns the cache hit and miss counts of the proxy
+func (p Proxy) CacheStats() CacheStats {
+	if p.counters == nil {
+		return CacheStats{}
+	}
+	return CacheStats{
+		Hits:          atomic.LoadUint64(&p.counters.hits),
+		Revalidations: atomic.LoadUint64(&p.counters.revalidations),
+		Misses:        atomic.LoadUint64(&p.counters.misses),
+		Coalesced:     atomic.LoadUint64(&p.counters.coalesced),
+	}
+}
+
+func (c *cacheCounters) hit() {
+	if c != nil {
+		atomic.AddUint64(&c.hits, 1)
+	}
+}
+
+func (c *cacheCounters) revalidated() {
+	if c != nil {
+		atomic.AddUint64(&c.revalidations, 1)
+	}
+}
+
+func (c *cacheCounters) miss() {
+	if c != nil {
+		atomic.AddUint64(&c.misses, 1)
+	}
+}
+
+//cacheTTL returns how long responses of endpoint are cached, zero when they are not. Single
+//resources like /api/trucks/marination use the TTL of their collection
+func (p Proxy) cacheTTL(endpoint string) time.Duration {
+	if p.Cache == nil {
+		return 0
+	}
+	if ttl, ok := p.CacheTTL[endpoint]; ok {
+		return ttl
+	}
+	return p.CacheTTL[path.Dir(endpoint)]
+}
+
+//MemoryCache is an in memory Cache. When full, expired entries are dropped first and then the
+//entries closest to expiring
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]CacheEntry
+	maxEntries int
+}
+
+//NewMemoryCache creates a memory cache holding up to maxEntries responses, zero means no limit
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]CacheEntry),
+		maxEntries: maxEntries,
+	}
+}
+
+//Get returns the entry stored for key
+func (c *MemoryCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+//Set stores entry for key
+func (c *MemoryCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict(time.Now())
+	}
+	c.entries[key] = entry
+}
+
+//Len returns the number of stored entries
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+//evict makes room for one entry, callers must hold the lock
+func (c *MemoryCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range c.entries {
+		if !e.Fresh(now) {
+			delete(c.entries, k)
+			continue
+		}
+		if oldestKey == "" || e.Expires.Before(oldest) {
+			oldestKey, oldest = k, e.Expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldestKey)
+	}
+}
+
+func (c *cacheCounters) coalesce() {
+	if c != nil {
+		atomic.AddUint64(&c.coalesced, 1)
+	}
+}
@@ -0,0 +1,94 @@
+package seattlefoodtruck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const neighborhoodsBody = `{"pagination":{"page":1,"total_pages":1,"total_count":1},"neighborhoods":[{"name":"Bellevue","id":"bellevue","uid":4}]}`
+
+func TestCacheServesFreshResponses(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(neighborhoodsBody))
+	}))
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	for i := 0; i < 3; i++ {
+		nr, err := p.GetNeighborhoods()
+		if err != nil || len(nr.Neighborhoods) != 1 {
+			t.Fatalf("Expected 1 neighborhood got %v, %v", nr, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 call got %d", calls)
+	}
+	if stats := p.CacheStats(); stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("Expected 2 hits and 1 miss got %+v", stats)
+	}
+}
+
+func TestCacheRevalidatesStaleResponses(t *testing.T) {
+	var conditional []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional = append(conditional, r.Header.Get("If-Modified-Since"))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Tue, 01 May 2018 08:00:00 GMT")
+		w.Write([]byte(neighborhoodsBody))
+	}))
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	p.CacheTTL = map[string]time.Duration{"/api/neighborhoods": time.Nanosecond}
+	p.GetNeighborhoods()
+	time.Sleep(time.Millisecond)
+	nr, err := p.GetNeighborhoods()
+	if err != nil || len(nr.Neighborhoods) != 1 || nr.Neighborhoods[0].ID != "bellevue" {
+		t.Fatalf("Expected cached neighborhood got %v, %v", nr, err)
+	}
+	if len(conditional) != 1 || conditional[0] != "Tue, 01 May 2018 08:00:00 GMT" {
+		t.Errorf("Expected one conditional request got %v", conditional)
+	}
+	if stats := p.CacheStats(); stats.Revalidations != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 revalidation and 1 miss got %+v", stats)
+	}
+}
+
+func TestCacheSkipsEndpointsWithoutTTL(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(neighborhoodsBody))
+	}))
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	p.CacheTTL = map[string]time.Duration{}
+	p.GetNeighborhoods()
+	p.GetNeighborhoods()
+	if calls != 2 {
+		t.Errorf("Expected 2 calls got %d", calls)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	c := NewMemoryCache(2)
+	now := time.Now()
+	c.Set("a", CacheEntry{Expires: now.Add(time.Hour)})
+	c.Set("b", CacheEntry{Expires: now.Add(time.Minute)})
+	c.Set("c", CacheEntry{Expires: now.Add(time.Hour)})
+	if c.Len() != 2 {
+		t.Fatalf("Expected 2 entries got %d", c.Len())
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Expected entry closest to expiring to be evicted")
+	}
+}
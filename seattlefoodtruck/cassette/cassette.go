@@ -0,0 +1,207 @@
+//Package cassette records http responses into cassette files and replays them, so tests can use
+//real api payloads without network access. Requests are matched on method, path and the query
+//string with its parameters sorted
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Version is the cassette file format version written by Save
+const Version = 1
+
+//Mode tells a Recorder whether to replay or record
+type Mode int
+
+const (
+	//ModeReplay answers requests from the cassette and never touches the network
+	ModeReplay Mode = iota
+	//ModeRecord sends requests to the real transport and captures the responses
+	ModeRecord
+)
+
+//ErrNoInteraction is returned when replaying a request that is not in the cassette
+var ErrNoInteraction = errors.New("cassette: no recorded interaction matches request")
+
+//Interaction is one recorded request and its response
+type Interaction struct {
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	Query      string      `json:"query"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+//Cassette is the content of a cassette file
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+//Recorder is an http.RoundTripper that records or replays a cassette
+type Recorder struct {
+	//Transport sends requests while recording, http.DefaultTransport when nil
+	Transport http.RoundTripper
+
+	mode     Mode
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+	//replayed counts how many times each request key was answered so repeated requests are
+	//replayed in recording order
+	replayed map[string]int
+}
+
+//New creates a recorder for the cassette file at path. In replay mode the file must exist
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		mode:     mode,
+		path:     path,
+		cassette: Cassette{Version: Version},
+		replayed: make(map[string]int),
+	}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cassette: invalid file %s: %v", path, err)
+	}
+	if r.cassette.Version != Version {
+		return nil, fmt.Errorf("cassette: %s has version %d, want %d", path, r.cassette.Version, Version)
+	}
+	return r, nil
+}
+
+//RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	//hop by hop and volatile headers make cassettes noisy without helping replay
+	for _, h := range []string{"Date", "Set-Cookie", "Connection", "Content-Length"} {
+		header.Del(h)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      NormalizeQuery(req.URL.RawQuery),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       string(body),
+	})
+	r.mu.Unlock()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	query := NormalizeQuery(req.URL.RawQuery)
+	key := req.Method + " " + req.URL.Path + "?" + query
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []Interaction
+	for _, i := range r.cassette.Interactions {
+		if i.Method == req.Method && i.Path == req.URL.Path && i.Query == query {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoInteraction, key)
+	}
+	//once all recordings were replayed keep answering with the last one
+	n := r.replayed[key]
+	r.replayed[key]++
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	i := matches[n]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(i.Body)),
+		ContentLength: int64(len(i.Body)),
+		Request:       req,
+	}, nil
+}
+
+//Interactions returns the interactions recorded or loaded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+//Save writes the recorded interactions to the cassette file. It does nothing in replay mode
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	//keep query strings and bodies readable in diffs
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	r.mu.Lock()
+	err := enc.Encode(r.cassette)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, buf.Bytes(), 0644)
+}
+
+//NormalizeQuery sorts query parameters by name and value so equivalent query strings compare equal
+func NormalizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for _, v := range values {
+		sort.Strings(v)
+	}
+	//Encode sorts by key
+	return values.Encode()
+}
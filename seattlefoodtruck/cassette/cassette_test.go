@@ -0,0 +1,69 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	a := NormalizeQuery("page=1&for_locations=44&include_bookings=true")
+	b := NormalizeQuery("include_bookings=true&page=1&for_locations=44")
+	if a != b {
+		t.Errorf("Expected '%v' to equal '%v'", a, b)
+	}
+	if got := NormalizeQuery("b=2&a=3&a=1"); got != "a=1&a=3&b=2" {
+		t.Errorf("Expected sorted query got '%v'", got)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(r.URL.Query().Get("page")))
+	}))
+	defer s.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, _ := New(path, ModeRecord)
+	client := &http.Client{Transport: rec}
+	for _, q := range []string{"?page=1&x=a", "?page=2&x=a"} {
+		resp, err := client.Get(s.URL + "/api/events" + q)
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	replay, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	client = &http.Client{Transport: replay}
+	//parameter order and host do not matter when matching
+	resp, err := client.Get("http://example.invalid/api/events?x=a&page=2")
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "2" || resp.Header.Get("ETag") != `"abc"` {
+		t.Errorf("Expected recorded response got '%s' %v", body, resp.Header)
+	}
+	if calls != 2 {
+		t.Errorf("Expected replay not to reach the server, got %d calls", calls)
+	}
+
+	_, err = client.Get("http://example.invalid/api/events?page=3")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Expected '%v' got '%v'", ErrNoInteraction, err)
+	}
+}
@@ -0,0 +1,30 @@
+package seattlefoodtruck
+
+import (
+	"context"
+)
+
+//Client is implemented by Proxy and FakeClient, the bot depends on it so it can run against either.
+//It holds the single page requests walks are built on and the GetAll methods the bot calls, Proxy
+//has the rest
+type Client interface {
+	GetNeighborhoodsPageContext(ctx context.Context, request *NeighborhoodRequest) (NeighborhoodResponse, error)
+	GetLocationsContext(ctx context.Context, request *LocationRequest) (LocationResponse, error)
+	GetLocationEventsContext(ctx context.Context, request *LocationEventsRequest) (LocationEventsResponse, error)
+	GetTruckContext(ctx context.Context, id string) (Truck, error)
+	GetTrucksContext(ctx context.Context, request *TruckRequest) (TrucksResponse, error)
+	GetTruckEventsContext(ctx context.Context, request *TruckEventsRequest) (LocationEventsResponse, error)
+	GetEventsContext(ctx context.Context, query EventsQuery) (LocationEventsResponse, error)
+
+	GetAllNeighborhoodsContext(ctx context.Context) ([]Neighborhood, error)
+	GetAllLocationsContext(ctx context.Context, request *LocationRequest) ([]Location, error)
+	GetAllTrucksContext(ctx context.Context) ([]Truck, error)
+	GetAllEventsContext(ctx context.Context, query EventsQuery) ([]Event, error)
+
+	CacheStats() CacheStats
+}
+
+var (
+	_ Client = Proxy{}
+	_ Client = (*FakeClient)(nil)
+)
@@ -0,0 +1,165 @@
+//Package emulator serves a local stand-in for the seattlefoodtruck.com api backed by a fixture, so
+//the proxy and the bot can run without network access
+package emulator
+
+import (
+	_ "embed" //for the default fixture
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//DefaultPageSize is how many items a page holds unless the server is told otherwise
+const DefaultPageSize = 25
+
+//defaultFixture is a small dataset modeled on real api responses
+//
+//go:embed fixture.json
+var defaultFixture []byte
+
+//DefaultFixture returns the dataset bundled with the emulator
+func DefaultFixture() seattlefoodtruck.Fixture {
+	var f seattlefoodtruck.Fixture
+	if err := json.Unmarshal(defaultFixture, &f); err != nil {
+		panic("emulator: bundled fixture is invalid: " + err.Error())
+	}
+	return f
+}
+
+//Server emulates the seattlefoodtruck.com api endpoints used by the proxy
+type Server struct {
+	//PageSize is how many items each page holds
+	PageSize int
+
+	fixture seattlefoodtruck.Fixture
+	mux     *http.ServeMux
+}
+
+//NewServer creates a server answering from fixture
+func NewServer(fixture seattlefoodtruck.Fixture) *Server {
+	s := &Server{
+		PageSize: DefaultPageSize,
+		fixture:  fixture,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/api/neighborhoods", s.neighborhoods)
+	s.mux.HandleFunc("/api/locations", s.locations)
+	s.mux.HandleFunc("/api/events", s.events)
+	s.mux.HandleFunc("/api/trucks", s.trucks)
+	s.mux.HandleFunc("/api/trucks/", s.truck)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	return s
+}
+
+//ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+//neighborhoods serves /api/neighborhoods?page=
+func (s *Server) neighborhoods(w http.ResponseWriter, r *http.Request) {
+	all := s.fixture.Neighborhoods
+	lo, hi, paging := s.paginate(len(all), r)
+	writeJSON(w, http.StatusOK, seattlefoodtruck.NeighborhoodResponse{
+		Pagination:    paging,
+		Neighborhoods: all[lo:hi],
+	})
+}
+
+//locations serves /api/locations?page=&neighborhood=&only_with_events=&with_active_trucks=
+func (s *Server) locations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var all []seattlefoodtruck.Location
+	if neighborhood := q.Get("neighborhood"); neighborhood != "" {
+		all = s.fixture.LocationsIn(neighborhood)
+	} else {
+		all = s.fixture.Locations
+	}
+	if flag(q.Get("only_with_events")) || flag(q.Get("with_active_trucks")) {
+		onlyActive := flag(q.Get("with_active_trucks"))
+		var filtered []seattlefoodtruck.Location
+		for _, l := range all {
+			for _, e := range s.fixture.FilterEvents([]int{l.UID}, "") {
+				if !onlyActive || len(e.Bookings) > 0 {
+					filtered = append(filtered, l)
+					break
+				}
+			}
+		}
+		all = filtered
+	}
+	lo, hi, paging := s.paginate(len(all), r)
+	writeJSON(w, http.StatusOK, seattlefoodtruck.LocationResponse{
+		Pagination: paging,
+		Locations:  all[lo:hi],
+	})
+}
+
+//events serves /api/events?page=&for_locations=&for_trucks=&start_date=&end_date=
+//&with_active_trucks=&include_bookings=&include_locations=&with_booking_status=
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	q, err := seattlefoodtruck.ParseEventsQuery(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	all := q.Apply(s.fixture.Events)
+	lo, hi, paging := s.paginate(len(all), r)
+	writeJSON(w, http.StatusOK, seattlefoodtruck.LocationEventsResponse{
+		Paging: paging,
+		Events: all[lo:hi],
+	})
+}
+
+//trucks serves /api/trucks?page=
+func (s *Server) trucks(w http.ResponseWriter, r *http.Request) {
+	all := s.fixture.Trucks
+	lo, hi, paging := s.paginate(len(all), r)
+	writeJSON(w, http.StatusOK, seattlefoodtruck.TrucksResponse{
+		Pagination: paging,
+		Trucks:     all[lo:hi],
+	})
+}
+
+//truck serves /api/trucks/{id}
+func (s *Server) truck(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.fixture.Truck(strings.TrimPrefix(r.URL.Path, "/api/trucks/"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+//paginate returns the bounds of the requested page and the matching pagination info
+func (s *Server) paginate(total int, r *http.Request) (int, int, seattlefoodtruck.Pagination) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	size := s.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	return seattlefoodtruck.Paginate(total, page, size)
+}
+
+func flag(value string) bool {
+	b, _ := strconv.ParseBool(value)
+	return b
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Failed to write response: ", err)
+	}
+}
@@ -0,0 +1,143 @@
+package emulator
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func newProxy(t *testing.T, pageSize int) seattlefoodtruck.Proxy {
+	s := NewServer(DefaultFixture())
+	s.PageSize = pageSize
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	p, _ := seattlefoodtruck.NewProxy(ts.URL)
+	p.Retry = seattlefoodtruck.RetryPolicy{}
+	return p
+}
+
+func TestProxyAgainstEmulator(t *testing.T) {
+	p := newProxy(t, 1)
+
+	neighborhoods, err := p.GetAllNeighborhoods()
+	if err != nil || len(neighborhoods) != 3 {
+		t.Errorf("Expected 3 neighborhoods got %d, %v", len(neighborhoods), err)
+	}
+	locations, err := p.GetAllLocations(&seattlefoodtruck.LocationRequest{Neighborhood: "bellevue"})
+	if err != nil || len(locations) != 2 {
+		t.Errorf("Expected 2 locations in bellevue got %d, %v", len(locations), err)
+	}
+	req := seattlefoodtruck.NewLocationEventsRequest(44, 1)
+	events, err := p.GetAllLocationEvents(&req)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events at location 44 got %d, %v", len(events), err)
+	}
+	for _, e := range events {
+		if e.Location.UID != 44 {
+			t.Errorf("Expected location 44 got %d", e.Location.UID)
+		}
+		for _, b := range e.Bookings {
+			if b.Status != "approved" {
+				t.Errorf("Expected only approved bookings got %v", b.Status)
+			}
+		}
+	}
+}
+
+func TestEmulatorUnknownEndpoint(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(DefaultFixture()).ServeHTTP(rec, httptest.NewRequest("GET", "/api/unknown", nil))
+	if rec.Code != 404 {
+		t.Errorf("Expected 404 got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	NewServer(DefaultFixture()).ServeHTTP(rec, httptest.NewRequest("GET", "/api/events?for_locations=abc", nil))
+	if rec.Code != 400 {
+		t.Errorf("Expected 400 got %d", rec.Code)
+	}
+}
+
+func TestEmulatorIncludeFlags(t *testing.T) {
+	//the proxy always asks for bookings and locations, check the endpoint leaves them out otherwise
+	rec := httptest.NewRecorder()
+	NewServer(DefaultFixture()).ServeHTTP(rec, httptest.NewRequest("GET", "/api/events?for_locations=44", nil))
+	if rec.Code != 200 {
+		t.Fatalf("Expected 200 got %d", rec.Code)
+	}
+	var r seattlefoodtruck.LocationEventsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil || len(r.Events) != 2 {
+		t.Fatalf("Expected 2 events got %s, %v", rec.Body, err)
+	}
+	for _, e := range r.Events {
+		if len(e.Bookings) != 0 || e.Location.UID != 0 {
+			t.Errorf("Expected no bookings or location in %+v", e)
+		}
+	}
+}
+
+func TestTrucksAgainstEmulator(t *testing.T) {
+	p := newProxy(t, 3)
+
+	trucks, err := p.GetAllTrucks()
+	if err != nil || len(trucks) != 4 {
+		t.Fatalf("Expected 4 trucks got %d, %v", len(trucks), err)
+	}
+	truck, err := p.GetTruck("Marination")
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if truck.UID != 301 || truck.Website == "" || len(truck.Photos) == 0 {
+		t.Errorf("Expected marination profile got %+v", truck)
+	}
+	_, err = p.GetTruck("no-such-truck")
+	if !errors.Is(err, seattlefoodtruck.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound got '%v'", err)
+	}
+	if _, err := p.GetTruck("marination"); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if stats := p.CacheStats(); stats.Hits != 1 {
+		t.Errorf("Expected the second lookup to be answered from the cache got %+v", stats)
+	}
+}
+
+func TestTruckEventsAgainstEmulator(t *testing.T) {
+	p := newProxy(t, 1)
+
+	req := seattlefoodtruck.NewTruckEventsRequest("nosh", 1)
+	events, err := p.GetAllTruckEvents(&req)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected nosh's one approved booking got %d, %v", len(events), err)
+	}
+	if events[0].Location.UID != 52 || !events[0].HasTruck("nosh") {
+		t.Errorf("Expected nosh at Bellevue City Hall got %+v", events[0])
+	}
+	req = seattlefoodtruck.NewTruckEventsRequest("marination", 1)
+	if events, err = p.GetAllTruckEvents(&req); err != nil || len(events) != 2 {
+		t.Errorf("Expected 2 events for marination got %d, %v", len(events), err)
+	}
+}
+
+func TestEventsQueryAgainstEmulator(t *testing.T) {
+	p := newProxy(t, 1)
+
+	day := time.Date(2018, 5, 1, 12, 0, 0, 0, seattlefoodtruck.Timezone())
+	q := seattlefoodtruck.NewEventsQuery().ForLocations(44, 52, 88).On(day).
+		WithBookingStatus(seattlefoodtruck.BookingApproved)
+	events, err := p.GetAllEvents(q)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("Expected 3 events on May 1st got %d, %v", len(events), err)
+	}
+	for _, e := range events {
+		if !e.OccursOn(day) {
+			t.Errorf("Expected only May 1st events got %v", e.StartTime)
+		}
+	}
+	if _, err := p.GetEvents(seattlefoodtruck.NewEventsQuery().WithBookingStatus("paid")); err == nil {
+		t.Errorf("Expected an invalid query to be rejected")
+	}
+}
@@ -0,0 +1,89 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//maxErrorBody is how much of a response body is kept on an APIError
+const maxErrorBody = 512
+
+var (
+	//ErrNotFound is matched by errors for requests the api answered with 404
+	ErrNotFound = errors.New("seattlefoodtruck: not found")
+	//ErrRateLimited is matched by errors for requests the api answered with 429
+	ErrRateLimited = errors.New("seattlefoodtruck: rate limited")
+	//ErrUnavailable is matched by errors for requests the api answered with a 5xx status or that
+	//could not reach the api at all
+	ErrUnavailable = errors.New("seattlefoodtruck: unavailable")
+	//ErrDecode is matched by errors for successful responses whose body is not the expected json
+	ErrDecode = errors.New("seattlefoodtruck: could not decode response")
+)
+
+//APIError describes a failed call to the seattle food truck api. Use errors.Is with the Err
+//sentinels to test for a class of failure and errors.As to get at the details
+type APIError struct {
+	//Endpoint is the api path that was called, e.g. /api/events
+	Endpoint string
+	//StatusCode is the http status of the response, zero when no response was received
+	StatusCode int
+	//URL is the full request url including the query string
+	URL string
+	//Body is the start of the response body, truncated to a few hundred bytes
+	Body string
+	//Err is the underlying transport or decode error, if any
+	Err error
+	//RetryAfter is how long the api asked us to wait before trying again, zero if it did not say
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := "seattlefoodtruck: GET " + e.Endpoint
+	switch {
+	case e.StatusCode == 0 && e.Err != nil:
+		msg += " failed: " + e.Err.Error()
+	case e.Err != nil:
+		msg += fmt.Sprintf(" returned %d with an invalid body: %v", e.StatusCode, e.Err)
+	default:
+		msg += fmt.Sprintf(" returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+//Unwrap returns the underlying transport or decode error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+//Is reports whether the error belongs to the class described by one of the Err sentinels
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		if e.StatusCode == 0 {
+			//a cancelled call says nothing about the api being up
+			return e.Err != nil && !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, context.DeadlineExceeded)
+		}
+		return e.StatusCode >= 500
+	case ErrDecode:
+		return e.StatusCode >= 200 && e.StatusCode < 300 && e.Err != nil
+	}
+	return false
+}
+
+//truncateBody shortens a response body so it can be carried on an error
+func truncateBody(body []byte) string {
+	if len(body) > maxErrorBody {
+		return string(body[:maxErrorBody]) + "..."
+	}
+	return string(body)
+}
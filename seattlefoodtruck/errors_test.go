@@ -0,0 +1,69 @@
+package seattlefoodtruck
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusNotFound, "<html>Not Found</html>", ErrNotFound},
+		{http.StatusTooManyRequests, "slow down", ErrRateLimited},
+		{http.StatusServiceUnavailable, "<html>Maintenance</html>", ErrUnavailable},
+		{http.StatusOK, "<html>not json</html>", ErrDecode},
+	}
+	for _, tt := range tests {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		p, _ := NewProxy(s.URL)
+		p.Retry = RetryPolicy{}
+		req := NewLocationEventsRequest(44, 1)
+		_, err := p.GetLocationEvents(&req)
+		s.Close()
+
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Expected '%v' got '%v'", tt.want, err)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected *APIError got %T", err)
+		}
+		if apiErr.Endpoint != "/api/events" || apiErr.StatusCode != tt.status || apiErr.Body != tt.body {
+			t.Errorf("Unexpected error details %+v", apiErr)
+		}
+		if !strings.Contains(apiErr.URL, "for_locations=44") {
+			t.Errorf("Expected request url in error got '%v'", apiErr.URL)
+		}
+	}
+}
+
+func TestAPIErrorTruncatesBody(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(strings.Repeat("x", 4*maxErrorBody)))
+	}))
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	p.Retry = RetryPolicy{}
+	_, err := p.GetNeighborhoods()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError got %T", err)
+	}
+	if len(apiErr.Body) > maxErrorBody+3 {
+		t.Errorf("Expected body truncated to %d got %d", maxErrorBody, len(apiErr.Body))
+	}
+	if errors.Is(err, ErrNotFound) || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected only ErrUnavailable to match '%v'", err)
+	}
+}
@@ -0,0 +1,121 @@
+package seattlefoodtruck
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" //containers often ship without zoneinfo
+)
+
+//DefaultTimezone is the zone event days are reckoned in, the trucks are in seattle
+const DefaultTimezone = "America/Los_Angeles"
+
+var timezone atomic.Value
+
+func init() {
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		panic("seattlefoodtruck: " + err.Error())
+	}
+	timezone.Store(loc)
+}
+
+//Timezone returns the reference zone event days are reckoned in
+func Timezone() *time.Location {
+	return timezone.Load().(*time.Location)
+}
+
+//SetTimezone changes the reference zone event days are reckoned in, nil restores DefaultTimezone
+func SetTimezone(loc *time.Location) {
+	if loc == nil {
+		loc, _ = time.LoadLocation(DefaultTimezone)
+	}
+	timezone.Store(loc)
+}
+
+//startOfDay returns midnight of t's date in the reference zone
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.In(Timezone()).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, Timezone())
+}
+
+//timestamp decodes a time the api sends, null and "" are the zero time rather than an error
+type timestamp time.Time
+
+//UnmarshalJSON decodes an RFC 3339 time, null or ""
+func (t *timestamp) UnmarshalJSON(data []byte) error {
+	if s := string(data); s == "null" || s == `""` {
+		*t = timestamp{}
+		return nil
+	}
+	var v time.Time
+	if err := v.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*t = timestamp(v)
+	return nil
+}
+
+//UnmarshalJSON decodes an event, times the api leaves null or empty are zero so one odd event does
+//not fail the whole page
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type plain Event
+	var v struct {
+		plain
+		StartTime timestamp `json:"start_time"`
+		EndTime   timestamp `json:"end_time"`
+		CreatedAt timestamp `json:"created_at"`
+		UpdatedAt timestamp `json:"updated_at"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = Event(v.plain)
+	e.StartTime, e.EndTime = time.Time(v.StartTime), time.Time(v.EndTime)
+	e.CreatedAt, e.UpdatedAt = time.Time(v.CreatedAt), time.Time(v.UpdatedAt)
+	return nil
+}
+
+//LocalStart returns the start time in the reference zone
+func (e Event) LocalStart() time.Time {
+	return e.StartTime.In(Timezone())
+}
+
+//LocalEnd returns the end time in the reference zone
+func (e Event) LocalEnd() time.Time {
+	return e.EndTime.In(Timezone())
+}
+
+//Duration returns how long the event lasts
+func (e Event) Duration() time.Duration {
+	if e.EndTime.Before(e.StartTime) {
+		return 0
+	}
+	return e.EndTime.Sub(e.StartTime)
+}
+
+//OccursOn reports whether the event takes place during date's day in the reference zone
+func (e Event) OccursOn(date time.Time) bool {
+	dayStart := startOfDay(date)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	if e.Duration() == 0 {
+		return !e.StartTime.Before(dayStart) && e.StartTime.Before(dayEnd)
+	}
+	return e.StartTime.Before(dayEnd) && e.EndTime.After(dayStart)
+}
+
+//IsActiveAt reports whether trucks are serving at t
+func (e Event) IsActiveAt(t time.Time) bool {
+	return !t.Before(e.StartTime) && t.Before(e.EndTime)
+}
+
+//HasTruck reports whether the truck with the given id is booked at the event
+func (e Event) HasTruck(id string) bool {
+	for _, b := range e.Bookings {
+		if strings.EqualFold(b.Truck.ID, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package seattlefoodtruck
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEventTimesDecode(t *testing.T) {
+	var e Event
+	data := `{"start_time":"2018-05-01T11:00:00.000-07:00","end_time":"2018-05-01T14:00:00.000-07:00","created_at":null}`
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		t.Fatalf("Failed to decode event: %v", err)
+	}
+	if e.Duration() != 3*time.Hour {
+		t.Errorf("Expected 3h got %v", e.Duration())
+	}
+	if got := e.LocalStart().Format(time.Kitchen); got != "11:00AM" {
+		t.Errorf("Expected 11:00AM got %v", got)
+	}
+}
+
+func TestEventsDecodeEmptyTimestamps(t *testing.T) {
+	data := `{"pagination":{"page":1,"total_pages":1,"total_count":2},"events":[
+		{"id":1,"start_time":"2018-05-01T11:00:00.000-07:00","end_time":"2018-05-01T14:00:00.000-07:00","created_at":null,"updated_at":""},
+		{"id":2,"start_time":"2018-05-01T17:00:00.000-07:00","end_time":"2018-05-01T20:00:00.000-07:00","created_at":"","updated_at":null,
+		 "bookings":[{"id":7,"status":"approved","truck":{"name":"Marination","id":"marination"}}]}]}`
+	var r LocationEventsResponse
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatalf("Expected null and empty timestamps to decode got '%v'", err)
+	}
+	if len(r.Events) != 2 || r.Events[1].ID != 2 || r.Events[1].Bookings[0].Truck.Name != "Marination" {
+		t.Fatalf("Unexpected events %+v", r.Events)
+	}
+	e := r.Events[1]
+	if !e.CreatedAt.IsZero() || !e.UpdatedAt.IsZero() || e.Duration() != 3*time.Hour {
+		t.Errorf("Expected zero created and updated times and a 3h event got %+v", e)
+	}
+
+	var bad Event
+	if err := json.Unmarshal([]byte(`{"start_time":"yesterday"}`), &bad); err == nil {
+		t.Error("Expected an error for a malformed time")
+	}
+}
+
+func TestEventOccursOnUsesReferenceTimezone(t *testing.T) {
+	defer SetTimezone(nil)
+	//a late event in seattle is already the next day in UTC
+	start := time.Date(2018, 5, 1, 17, 0, 0, 0, Timezone())
+	e := Event{StartTime: start, EndTime: start.Add(3 * time.Hour)}
+
+	utcNextDay := time.Date(2018, 5, 2, 1, 0, 0, 0, time.UTC)
+	if !e.OccursOn(utcNextDay) {
+		t.Errorf("Expected event to occur on May 1st in seattle")
+	}
+	if e.OccursOn(time.Date(2018, 5, 2, 12, 0, 0, 0, Timezone())) {
+		t.Errorf("Expected event not to occur on May 2nd in seattle")
+	}
+
+	SetTimezone(time.UTC)
+	if !e.OccursOn(utcNextDay) {
+		t.Errorf("Expected event running past midnight to occur on May 2nd in UTC")
+	}
+	if e.OccursOn(time.Date(2018, 5, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected event not to occur on May 3rd in UTC")
+	}
+}
+
+func TestEventIsActiveAt(t *testing.T) {
+	start := time.Date(2018, 5, 1, 11, 0, 0, 0, Timezone())
+	e := Event{StartTime: start, EndTime: start.Add(3 * time.Hour)}
+	tests := map[time.Duration]bool{
+		-time.Minute:  false,
+		0:             true,
+		2 * time.Hour: true,
+		3 * time.Hour: false,
+	}
+	for offset, want := range tests {
+		if got := e.IsActiveAt(start.Add(offset)); got != want {
+			t.Errorf("IsActiveAt(start+%v) expected %v got %v", offset, want, got)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+//FakeClient is an in memory Client serving a Fixture, for tests and demos that must not reach
+//seattlefoodtruck.com. It pages and filters like the api
+type FakeClient struct {
+	//PageSize is how many items each page holds, zero puts everything on one page
+	PageSize int
+	//MaxPages caps how many pages the GetAll methods will fetch, zero means DefaultMaxPages
+	MaxPages int
+
+	mu      sync.Mutex
+	fixture Fixture
+	err     error
+	calls   int
+}
+
+//NewFakeClient creates a fake client serving fixture
+func NewFakeClient(fixture Fixture) *FakeClient {
+	return &FakeClient{fixture: fixture}
+}
+
+//NewFakeClientFromFile creates a fake client serving the json fixture at path
+func NewFakeClientFromFile(path string) (*FakeClient, error) {
+	f, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFakeClient(f), nil
+}
+
+//SetFixture replaces the data served by the fake
+func (f *FakeClient) SetFixture(fixture Fixture) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fixture = fixture
+}
+
+//SetError makes every following call fail with err, nil restores normal behavior
+func (f *FakeClient) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+//Calls returns how many single page requests the fake has answered
+func (f *FakeClient) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+//begin records a call and returns the fixture to serve it from
+func (f *FakeClient) begin(ctx context.Context) (Fixture, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if err := ctx.Err(); err != nil {
+		return Fixture{}, err
+	}
+	return f.fixture, f.err
+}
+
+//GetNeighborhoodsPageContext gets a specific page of neighborhoods with a custom context
+func (f *FakeClient) GetNeighborhoodsPageContext(ctx context.Context, request *NeighborhoodRequest) (NeighborhoodResponse, error) {
+	var nr NeighborhoodResponse
+	if request == nil {
+		return nr, fmt.Errorf("Invalid Request")
+	}
+	fixture, err := f.begin(ctx)
+	if err != nil {
+		return nr, err
+	}
+	lo, hi, paging := Paginate(len(fixture.Neighborhoods), request.Page, f.PageSize)
+	nr.Pagination = paging
+	nr.Neighborhoods = fixture.Neighborhoods[lo:hi]
+	return nr, nil
+}
+
+//GetLocationsContext gets a page of locations in a neighborhood with a custom context
+func (f *FakeClient) GetLocationsContext(ctx context.Context, request *LocationRequest) (LocationResponse, error) {
+	var lr LocationResponse
+	if request == nil {
+		return lr, fmt.Errorf("Invalid Request")
+	}
+	fixture, err := f.begin(ctx)
+	if err != nil {
+		return lr, err
+	}
+	locations := fixture.LocationsIn(request.withDefaults().Neighborhood)
+	lo, hi, paging := Paginate(len(locations), request.Page, f.PageSize)
+	lr.Pagination = paging
+	lr.Locations = locations[lo:hi]
+	return lr, nil
+}
+
+//GetLocationEventsContext gets a page of events at a location with a custom context
+func (f *FakeClient) GetLocationEventsContext(ctx context.Context, request *LocationEventsRequest) (LocationEventsResponse, error) {
+	if request == nil {
+		return LocationEventsResponse{}, fmt.Errorf("Invalid Request")
+	}
+	return f.GetEventsContext(ctx, request.Query())
+}
+
+//GetTruckContext gets the truck with the given id with a custom context
+func (f *FakeClient) GetTruckContext(ctx context.Context, id string) (Truck, error) {
+	fixture, err := f.begin(ctx)
+	if err != nil {
+		return Truck{}, err
+	}
+	t, ok := fixture.Truck(id)
+	if !ok {
+		return t, &APIError{Endpoint: truckEndpoint(id), StatusCode: http.StatusNotFound}
+	}
+	return t, nil
+}
+
+//GetTrucksContext gets a page of trucks with a custom context
+func (f *FakeClient) GetTrucksContext(ctx context.Context, request *TruckRequest) (TrucksResponse, error) {
+	var tr TrucksResponse
+	if request == nil {
+		return tr, fmt.Errorf("Invalid Request")
+	}
+	fixture, err := f.begin(ctx)
+	if err != nil {
+		return tr, err
+	}
+	lo, hi, paging := Paginate(len(fixture.Trucks), request.Page, f.PageSize)
+	tr.Pagination = paging
+	tr.Trucks = fixture.Trucks[lo:hi]
+	return tr, nil
+}
+
+//GetTruckEventsContext gets a page of events the truck in request is booked at with a custom context
+func (f *FakeClient) GetTruckEventsContext(ctx context.Context, request *TruckEventsRequest) (LocationEventsResponse, error) {
+	if request == nil {
+		return LocationEventsResponse{}, fmt.Errorf("Invalid Request")
+	}
+	return f.GetEventsContext(ctx, request.Query())
+}
+
+//GetEventsContext gets a page of events matching query with a custom context
+func (f *FakeClient) GetEventsContext(ctx context.Context, query EventsQuery) (LocationEventsResponse, error) {
+	var r LocationEventsResponse
+	if err := query.Validate(); err != nil {
+		return r, err
+	}
+	fixture, err := f.begin(ctx)
+	if err != nil {
+		return r, err
+	}
+	events := query.Apply(fixture.Events)
+	lo, hi, paging := Paginate(len(events), query.page, f.PageSize)
+	r.Paging = paging
+	r.Events = events[lo:hi]
+	return r, nil
+}
+
+//GetAllNeighborhoodsContext gets neighborhoods across all pages with a custom context
+func (f *FakeClient) GetAllNeighborhoodsContext(ctx context.Context) ([]Neighborhood, error) {
+	return getAllNeighborhoods(ctx, f, f.MaxPages)
+}
+
+//GetAllLocationsContext gets locations matching request across all pages with a custom context
+func (f *FakeClient) GetAllLocationsContext(ctx context.Context, request *LocationRequest) ([]Location, error) {
+	return getAllLocations(ctx, f, f.MaxPages, request)
+}
+
+//GetAllTrucksContext gets trucks across all pages with a custom context
+func (f *FakeClient) GetAllTrucksContext(ctx context.Context) ([]Truck, error) {
+	return getAllTrucks(ctx, f, f.MaxPages)
+}
+
+//GetAllEventsContext gets events matching query across all pages with a custom context
+func (f *FakeClient) GetAllEventsContext(ctx context.Context, query EventsQuery) ([]Event, error) {
+	return getAllEvents(ctx, f, f.MaxPages, query)
+}
+
+//CacheStats always returns empty stats, the fake has no cache
+func (f *FakeClient) CacheStats() CacheStats {
+	return CacheStats{}
+}
@@ -0,0 +1,48 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFakeClientFromFixture(t *testing.T) {
+	f, err := NewFakeClientFromFile("emulator/fixture.json")
+	if err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	f.PageSize = 1
+	ctx := context.Background()
+
+	neighborhoods, err := f.GetAllNeighborhoodsContext(ctx)
+	if err != nil || len(neighborhoods) != 3 {
+		t.Errorf("Expected 3 neighborhoods got %d, %v", len(neighborhoods), err)
+	}
+	locations, err := f.GetAllLocationsContext(ctx, &LocationRequest{Neighborhood: "bellevue"})
+	if err != nil || len(locations) != 2 {
+		t.Errorf("Expected 2 locations in bellevue got %d, %v", len(locations), err)
+	}
+	events, err := f.GetAllEventsContext(ctx, NewLocationEventsRequest(44, 1).Query())
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events at location 44 got %d, %v", len(events), err)
+	}
+	//the cancelled booking is filtered out like the api does
+	if len(events[0].Bookings) != 2 {
+		t.Errorf("Expected 2 approved bookings got %d", len(events[0].Bookings))
+	}
+	if f.Calls() != 3+2+2 {
+		t.Errorf("Expected one call per page got %d", f.Calls())
+	}
+}
+
+func TestFakeClientError(t *testing.T) {
+	f := NewFakeClient(Fixture{})
+	f.SetError(ErrUnavailable)
+	if _, err := f.GetNeighborhoodsPageContext(context.Background(), &NeighborhoodRequest{Page: 1}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected '%v' got '%v'", ErrUnavailable, err)
+	}
+	f.SetError(nil)
+	if _, err := f.GetNeighborhoodsPageContext(context.Background(), &NeighborhoodRequest{Page: 1}); err != nil {
+		t.Errorf("Expected no error got '%v'", err)
+	}
+}
@@ -0,0 +1,141 @@
+package seattlefoodtruck
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+)
+
+//Fixture is a dataset of neighborhoods, locations and events shaped like the api responses. It
+//backs FakeClient and can be loaded from a json file
+type Fixture struct {
+	Neighborhoods []Neighborhood `json:"neighborhoods"`
+	Locations     []Location     `json:"locations"`
+	Events        []Event        `json:"events"`
+	Trucks        []Truck        `json:"trucks"`
+}
+
+//LoadFixture reads a fixture from a json file
+func LoadFixture(path string) (Fixture, error) {
+	var f Fixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return f, err
+	}
+	err = json.Unmarshal(data, &f)
+	return f, err
+}
+
+//LocationsIn returns the locations in the neighborhood with the given id, e.g. bellevue
+func (f Fixture) LocationsIn(neighborhood string) []Location {
+	var uid int
+	found := false
+	for _, n := range f.Neighborhoods {
+		if strings.EqualFold(n.ID, neighborhood) {
+			uid, found = n.UID, true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	var locations []Location
+	for _, l := range f.Locations {
+		if l.NeighborhoodID == uid || l.Neighborhood.ID == uid {
+			locations = append(locations, l)
+		}
+	}
+	return locations
+}
+
+//Truck returns the truck with the given id
+func (f Fixture) Truck(id string) (Truck, bool) {
+	for _, t := range f.Trucks {
+		if strings.EqualFold(t.ID, id) {
+			return t, true
+		}
+	}
+	return Truck{}, false
+}
+
+//EventsAt returns the events at the location with the given uid, keeping only approved bookings
+//like the api does for a LocationEventsRequest
+func (f Fixture) EventsAt(location int) []Event {
+	return f.FilterEvents([]int{location}, BookingApproved)
+}
+
+//EventsFor returns the events the truck with the given id has an approved booking at, like the api
+//does for a TruckEventsRequest
+func (f Fixture) EventsFor(truck string) []Event {
+	return NewEventsQuery().ForTrucks(truck).WithBookingStatus(BookingApproved).Apply(f.Events)
+}
+
+//FilterEvents returns the events at any of locations, all events when locations is empty. When
+//bookingStatus is set only bookings with that status are kept
+func (f Fixture) FilterEvents(locations []int, bookingStatus string) []Event {
+	return NewEventsQuery().ForLocations(locations...).WithBookingStatus(bookingStatus).Apply(f.Events)
+}
+
+//ShiftedTo returns a copy of the fixture with all event times moved by whole days so the earliest
+//event falls on the same date as day in the reference timezone. It keeps recorded schedules useful
+//for demos
+func (f Fixture) ShiftedTo(day time.Time) Fixture {
+	var first time.Time
+	for _, e := range f.Events {
+		if first.IsZero() || e.StartTime.Before(first) {
+			first = e.StartTime
+		}
+	}
+	if first.IsZero() {
+		return f
+	}
+	//count calendar days in UTC so daylight saving changes don't produce fractional days
+	y, m, d := first.In(Timezone()).Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	y, m, d = day.In(Timezone()).Date()
+	to := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	days := int(to.Sub(from).Hours() / 24)
+
+	events := make([]Event, len(f.Events))
+	for i, e := range f.Events {
+		e.StartTime = e.StartTime.AddDate(0, 0, days)
+		e.EndTime = e.EndTime.AddDate(0, 0, days)
+		events[i] = e
+	}
+	f.Events = events
+	return f
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+//Paginate returns the bounds of page in a list of total items and the matching pagination info, as
+//the api pages its lists. A pageSize of zero puts everything on one page
+func Paginate(total int, page int, pageSize int) (int, int, Pagination) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = total
+	}
+	paging := Pagination{Page: page, TotalCount: total}
+	if pageSize > 0 {
+		paging.TotalPages = (total + pageSize - 1) / pageSize
+	}
+	lo := (page - 1) * pageSize
+	if lo > total {
+		lo = total
+	}
+	hi := lo + pageSize
+	if hi > total {
+		hi = total
+	}
+	return lo, hi, paging
+}
@@ -0,0 +1,83 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"sync"
+)
+
+//flightGroup coalesces identical requests in flight so only one of them reaches the api and every
+//caller shares its response. It is safe for concurrent use, a nil group coalesces nothing
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+//flight is a request in progress and the callers waiting on it
+type flight struct {
+	done    chan struct{}
+	entry   CacheEntry
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+//do calls fn once for concurrent callers with the same key and hands each of them its result.
+//shared reports whether the caller joined a call started by another. fn runs with a context that
+//keeps the values of the first caller's context and is cancelled once every caller has given up,
+//so one impatient caller does not fail the others
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (CacheEntry, error)) (entry CacheEntry, shared bool, err error) {
+	if g == nil {
+		entry, err = fn(ctx)
+		return entry, false, err
+	}
+	if err := ctx.Err(); err != nil {
+		return entry, false, err
+	}
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	f, shared := g.calls[key]
+	if shared {
+		f.waiters++
+	} else {
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), waiters: 1, cancel: cancel}
+		g.calls[key] = f
+		go func() {
+			f.entry, f.err = fn(fctx)
+			g.forget(key, f)
+			cancel()
+			close(f.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.entry, shared, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			//nobody wants the answer anymore, later callers start afresh
+			f.cancel()
+			g.forgetLocked(key, f)
+		}
+		g.mu.Unlock()
+		return CacheEntry{}, shared, ctx.Err()
+	}
+}
+
+//forget removes f from the group unless a newer call took its place
+func (g *flightGroup) forget(key string, f *flight) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.forgetLocked(key, f)
+}
+
+func (g *flightGroup) forgetLocked(key string, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
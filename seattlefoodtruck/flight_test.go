@@ -0,0 +1,107 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//newBlockingServer answers neighborhood requests once release is closed, counting the requests
+func newBlockingServer(release chan struct{}, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		<-release
+		w.Write([]byte(`{"pagination":{"page":1,"total_pages":1,"total_count":1},"neighborhoods":[{"id":"bellevue"}]}`))
+	}))
+}
+
+//waitForWaiters blocks until n callers wait on the flight for key
+func waitForWaiters(t *testing.T, g *flightGroup, key string, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		f := g.calls[key]
+		joined := f != nil && f.waiters == n
+		g.mu.Unlock()
+		if joined {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d callers to join", n)
+}
+
+func TestProxyCoalescesIdenticalRequests(t *testing.T) {
+	release := make(chan struct{})
+	var requests int32
+	s := newBlockingServer(release, &requests)
+	defer s.Close()
+	p, _ := NewProxy(s.URL)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nr, err := p.GetNeighborhoods()
+			if err == nil && (len(nr.Neighborhoods) != 1 || nr.Neighborhoods[0].ID != "bellevue") {
+				t.Errorf("Expected bellevue got %+v", nr)
+			}
+			errs <- err
+		}()
+	}
+	waitForWaiters(t, p.flights, "/api/neighborhoods", callers)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Expected no error got '%v'", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request to the api got %d", requests)
+	}
+	if stats := p.CacheStats(); stats.Misses != 1 || stats.Coalesced != callers-1 {
+		t.Errorf("Expected 1 miss and %d coalesced requests got %+v", callers-1, stats)
+	}
+}
+
+func TestCoalescedCallerGivingUpDoesNotFailOthers(t *testing.T) {
+	release := make(chan struct{})
+	var requests int32
+	s := newBlockingServer(release, &requests)
+	defer s.Close()
+	p, _ := NewProxy(s.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := p.GetNeighborhoodsContext(ctx)
+		first <- err
+	}()
+	waitForWaiters(t, p.flights, "/api/neighborhoods", 1)
+	second := make(chan error, 1)
+	go func() {
+		_, err := p.GetNeighborhoods()
+		second <- err
+	}()
+	waitForWaiters(t, p.flights, "/api/neighborhoods", 2)
+	cancel()
+	if err := <-first; err == nil {
+		t.Errorf("Expected the cancelled caller to fail")
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("Expected the other caller to succeed got '%v'", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request to the api got %d", requests)
+	}
+}
@@ -0,0 +1,251 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+)
+
+//DefaultMaxPages is the default number of pages a walk will fetch before giving up
+const DefaultMaxPages = 20
+
+//ErrStopWalk can be returned by a walk func to stop paging without an error
+var ErrStopWalk = errors.New("stop walk")
+
+//ErrPageLimit is returned when a walk stopped because it reached the page cap before the last page
+var ErrPageLimit = errors.New("page limit reached before last page")
+
+//walkPages fetches pages starting at start until the last page reported by the api, or the page cap
+//is reached or ctx is done. fetch returns the pagination info of the page it fetched
+func walkPages(ctx context.Context, start int, maxPages int, fetch func(page int) (Pagination, error)) error {
+	if start <= 0 {
+		start = 1
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	for page, fetched := start, 0; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if fetched == maxPages {
+			return ErrPageLimit
+		}
+		paging, err := fetch(page)
+		fetched++
+		if err == ErrStopWalk {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		//the api reports total pages, stop when we have seen the last one
+		if page >= paging.TotalPages {
+			return nil
+		}
+	}
+}
+
+//pageGetter fetches single pages, walks are built on top of it so every Client pages the same way
+type pageGetter interface {
+	GetNeighborhoodsPageContext(ctx context.Context, request *NeighborhoodRequest) (NeighborhoodResponse, error)
+	GetLocationsContext(ctx context.Context, request *LocationRequest) (LocationResponse, error)
+	GetLocationEventsContext(ctx context.Context, request *LocationEventsRequest) (LocationEventsResponse, error)
+	GetTrucksContext(ctx context.Context, request *TruckRequest) (TrucksResponse, error)
+	GetTruckEventsContext(ctx context.Context, request *TruckEventsRequest) (LocationEventsResponse, error)
+	GetEventsContext(ctx context.Context, query EventsQuery) (LocationEventsResponse, error)
+}
+
+func walkNeighborhoods(ctx context.Context, c pageGetter, maxPages int, fn func(NeighborhoodResponse) error) error {
+	return walkPages(ctx, 1, maxPages, func(page int) (Pagination, error) {
+		resp, err := c.GetNeighborhoodsPageContext(ctx, &NeighborhoodRequest{Page: page})
+		if err != nil {
+			return resp.Pagination, err
+		}
+		return resp.Pagination, fn(resp)
+	})
+}
+
+func walkLocations(ctx context.Context, c pageGetter, maxPages int, request *LocationRequest, fn func(LocationResponse) error) error {
+	if request == nil {
+		return errors.New("Invalid Request")
+	}
+	lr := *request
+	return walkPages(ctx, lr.Page, maxPages, func(page int) (Pagination, error) {
+		lr.Page = page
+		resp, err := c.GetLocationsContext(ctx, &lr)
+		if err != nil {
+			return resp.Pagination, err
+		}
+		return resp.Pagination, fn(resp)
+	})
+}
+
+func walkLocationEvents(ctx context.Context, c pageGetter, maxPages int, request *LocationEventsRequest, fn func(LocationEventsResponse) error) error {
+	if request == nil {
+		return errors.New("Invalid Request")
+	}
+	ler := *request
+	return walkPages(ctx, ler.Page, maxPages, func(page int) (Pagination, error) {
+		ler.Page = page
+		resp, err := c.GetLocationEventsContext(ctx, &ler)
+		if err != nil {
+			return resp.Paging, err
+		}
+		return resp.Paging, fn(resp)
+	})
+}
+
+func walkTrucks(ctx context.Context, c pageGetter, maxPages int, fn func(TrucksResponse) error) error {
+	return walkPages(ctx, 1, maxPages, func(page int) (Pagination, error) {
+		resp, err := c.GetTrucksContext(ctx, &TruckRequest{Page: page})
+		if err != nil {
+			return resp.Pagination, err
+		}
+		return resp.Pagination, fn(resp)
+	})
+}
+
+func walkTruckEvents(ctx context.Context, c pageGetter, maxPages int, request *TruckEventsRequest, fn func(LocationEventsResponse) error) error {
+	if request == nil {
+		return errors.New("Invalid Request")
+	}
+	ter := *request
+	return walkPages(ctx, ter.Page, maxPages, func(page int) (Pagination, error) {
+		ter.Page = page
+		resp, err := c.GetTruckEventsContext(ctx, &ter)
+		if err != nil {
+			return resp.Paging, err
+		}
+		return resp.Paging, fn(resp)
+	})
+}
+
+func walkEvents(ctx context.Context, c pageGetter, maxPages int, query EventsQuery, fn func(LocationEventsResponse) error) error {
+	if err := query.Validate(); err != nil {
+		return err
+	}
+	return walkPages(ctx, query.page, maxPages, func(page int) (Pagination, error) {
+		resp, err := c.GetEventsContext(ctx, query.Page(page))
+		if err != nil {
+			return resp.Paging, err
+		}
+		return resp.Paging, fn(resp)
+	})
+}
+
+func getAllNeighborhoods(ctx context.Context, c pageGetter, maxPages int) ([]Neighborhood, error) {
+	var neighborhoods []Neighborhood
+	err := walkNeighborhoods(ctx, c, maxPages, func(resp NeighborhoodResponse) error {
+		neighborhoods = append(neighborhoods, resp.Neighborhoods...)
+		return nil
+	})
+	return neighborhoods, err
+}
+
+func getAllLocations(ctx context.Context, c pageGetter, maxPages int, request *LocationRequest) ([]Location, error) {
+	var locations []Location
+	err := walkLocations(ctx, c, maxPages, request, func(resp LocationResponse) error {
+		locations = append(locations, resp.Locations...)
+		return nil
+	})
+	return locations, err
+}
+
+func getAllLocationEvents(ctx context.Context, c pageGetter, maxPages int, request *LocationEventsRequest) ([]Event, error) {
+	var events []Event
+	err := walkLocationEvents(ctx, c, maxPages, request, func(resp LocationEventsResponse) error {
+		events = append(events, resp.Events...)
+		return nil
+	})
+	return events, err
+}
+
+func getAllTrucks(ctx context.Context, c pageGetter, maxPages int) ([]Truck, error) {
+	var trucks []Truck
+	err := walkTrucks(ctx, c, maxPages, func(resp TrucksResponse) error {
+		trucks = append(trucks, resp.Trucks...)
+		return nil
+	})
+	return trucks, err
+}
+
+//WalkNeighborhoods calls fn for every page of neighborhoods
+func (p Proxy) WalkNeighborhoods(fn func(NeighborhoodResponse) error) error {
+	return p.WalkNeighborhoodsContext(context.Background(), fn)
+}
+
+//WalkNeighborhoodsContext calls fn for every page of neighborhoods with a custom context
+func (p Proxy) WalkNeighborhoodsContext(ctx context.Context, fn func(NeighborhoodResponse) error) error {
+	return walkNeighborhoods(ctx, p, p.MaxPages, fn)
+}
+
+//WalkLocations calls fn for every page of locations matching request, starting at request.Page
+func (p Proxy) WalkLocations(request *LocationRequest, fn func(LocationResponse) error) error {
+	return p.WalkLocationsContext(context.Background(), request, fn)
+}
+
+//WalkLocationsContext calls fn for every page of locations matching request with a custom context
+func (p Proxy) WalkLocationsContext(ctx context.Context, request *LocationRequest, fn func(LocationResponse) error) error {
+	return walkLocations(ctx, p, p.MaxPages, request, fn)
+}
+
+//WalkLocationEvents calls fn for every page of events matching request, starting at request.Page
+func (p Proxy) WalkLocationEvents(request *LocationEventsRequest, fn func(LocationEventsResponse) error) error {
+	return p.WalkLocationEventsContext(context.Background(), request, fn)
+}
+
+//WalkLocationEventsContext calls fn for every page of events matching request with a custom context
+func (p Proxy) WalkLocationEventsContext(ctx context.Context, request *LocationEventsRequest, fn func(LocationEventsResponse) error) error {
+	return walkLocationEvents(ctx, p, p.MaxPages, request, fn)
+}
+
+//GetAllNeighborhoods gets neighborhoods across all pages. When the page cap is reached the
+//neighborhoods fetched so far are returned along with ErrPageLimit
+func (p Proxy) GetAllNeighborhoods() ([]Neighborhood, error) {
+	return p.GetAllNeighborhoodsContext(context.Background())
+}
+
+//GetAllNeighborhoodsContext gets neighborhoods across all pages with a custom context
+func (p Proxy) GetAllNeighborhoodsContext(ctx context.Context) ([]Neighborhood, error) {
+	return getAllNeighborhoods(ctx, p, p.MaxPages)
+}
+
+//GetAllLocations gets locations matching request across all pages. When the page cap is reached the
+//locations fetched so far are returned along with ErrPageLimit
+func (p Proxy) GetAllLocations(request *LocationRequest) ([]Location, error) {
+	return p.GetAllLocationsContext(context.Background(), request)
+}
+
+//GetAllLocationsContext gets locations matching request across all pages with a custom context
+func (p Proxy) GetAllLocationsContext(ctx context.Context, request *LocationRequest) ([]Location, error) {
+	return getAllLocations(ctx, p, p.MaxPages, request)
+}
+
+//GetAllLocationEvents gets events matching request across all pages. When the page cap is reached the
+//events fetched so far are returned along with ErrPageLimit
+func (p Proxy) GetAllLocationEvents(request *LocationEventsRequest) ([]Event, error) {
+	return p.GetAllLocationEventsContext(context.Background(), request)
+}
+
+//GetAllLocationEventsContext gets events matching request across all pages with a custom context
+func (p Proxy) GetAllLocationEventsContext(ctx context.Context, request *LocationEventsRequest) ([]Event, error) {
+	return getAllLocationEvents(ctx, p, p.MaxPages, request)
+}
+
+func getAllTruckEvents(ctx context.Context, c pageGetter, maxPages int, request *TruckEventsRequest) ([]Event, error) {
+	var events []Event
+	err := walkTruckEvents(ctx, c, maxPages, request, func(resp LocationEventsResponse) error {
+		events = append(events, resp.Events...)
+		return nil
+	})
+	return events, err
+}
+
+func getAllEvents(ctx context.Context, c pageGetter, maxPages int, query EventsQuery) ([]Event, error) {
+	var events []Event
+	err := walkEvents(ctx, c, maxPages, query, func(resp LocationEventsResponse) error {
+		events = append(events, resp.Events...)
+		return nil
+	})
+	return events, err
+}
@@ -0,0 +1,110 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//newPagedServer serves totalPages pages of location events with one event per page
+func newPagedServer(totalPages int, requested *[]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		*requested = append(*requested, page)
+		fmt.Fprintf(w, `{"pagination":{"page":%d,"total_pages":%d,"total_count":%d},"events":[{"id":%d}]}`,
+			page, totalPages, totalPages, page)
+	}))
+}
+
+func TestGetAllLocationEventsFollowsPages(t *testing.T) {
+	var requested []int
+	s := newPagedServer(3, &requested)
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	req := NewLocationEventsRequest(44, 1)
+	events, err := p.GetAllLocationEvents(&req)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events got %d", len(events))
+	}
+	for i, e := range events {
+		if e.ID != i+1 {
+			t.Errorf("Expected event %d got %d", i+1, e.ID)
+		}
+	}
+}
+
+func TestWalkStopsAtPageLimit(t *testing.T) {
+	var requested []int
+	s := newPagedServer(10, &requested)
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	p.MaxPages = 2
+	req := NewLocationEventsRequest(44, 1)
+	events, err := p.GetAllLocationEvents(&req)
+	if err != ErrPageLimit {
+		t.Fatalf("Expected '%v' got '%v'", ErrPageLimit, err)
+	}
+	if len(events) != 2 || len(requested) != 2 {
+		t.Errorf("Expected 2 events from 2 requests got %d from %d", len(events), len(requested))
+	}
+}
+
+func TestWalkStopsEarly(t *testing.T) {
+	var requested []int
+	s := newPagedServer(5, &requested)
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	req := NewLocationEventsRequest(44, 1)
+	err := p.WalkLocationEvents(&req, func(resp LocationEventsResponse) error {
+		return ErrStopWalk
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(requested) != 1 {
+		t.Errorf("Expected 1 request got %d", len(requested))
+	}
+}
+
+func TestWalkHonorsCancelledContext(t *testing.T) {
+	var requested []int
+	s := newPagedServer(3, &requested)
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := p.GetAllNeighborhoodsContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("Expected '%v' got '%v'", context.Canceled, err)
+	}
+	if len(requested) != 0 {
+		t.Errorf("Expected no requests got %d", len(requested))
+	}
+}
+
+func TestProxyTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	p.Timeout = 20 * time.Millisecond
+	p.Retry = RetryPolicy{}
+	_, err := p.GetNeighborhoods()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected '%v' got '%v'", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,344 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Booking statuses the api filters events by
+const (
+	BookingApproved  = "approved"
+	BookingPending   = "pending"
+	BookingCancelled = "cancelled"
+)
+
+//dateLayout is how start and end dates are sent to the api
+const dateLayout = "2006-01-02"
+
+//EventsQuery selects events from the api. Build one with NewEventsQuery and the chained setters,
+//each returns a modified copy so a query can be shared and extended safely:
+//
+//	q := NewEventsQuery().ForLocations(44, 52).On(time.Now()).WithBookingStatus(BookingApproved)
+type EventsQuery struct {
+	locations        []int
+	trucks           []string
+	start            time.Time
+	end              time.Time
+	bookingStatus    string
+	activeTrucks     bool
+	includeBookings  bool
+	includeLocations bool
+	page             int
+}
+
+//NewEventsQuery returns a query for the first page of events at any location, including bookings
+//and locations
+func NewEventsQuery() EventsQuery {
+	return EventsQuery{
+		includeBookings:  true,
+		includeLocations: true,
+		page:             1,
+	}
+}
+
+//ForLocations limits the query to events at any of the locations with the given uids
+func (q EventsQuery) ForLocations(uids ...int) EventsQuery {
+	q.locations = append(append([]int(nil), q.locations...), uids...)
+	return q
+}
+
+//ForTrucks limits the query to events any of the trucks with the given ids is booked at
+func (q EventsQuery) ForTrucks(ids ...string) EventsQuery {
+	trucks := append([]string(nil), q.trucks...)
+	for _, id := range ids {
+		trucks = append(trucks, strings.ToLower(strings.TrimSpace(id)))
+	}
+	q.trucks = trucks
+	return q
+}
+
+//Between limits the query to events taking place from the start date through the end date, both
+//reckoned in the reference timezone. A zero time leaves that side of the window open
+func (q EventsQuery) Between(start, end time.Time) EventsQuery {
+	q.start, q.end = start, end
+	return q
+}
+
+//On limits the query to events taking place on day's date in the reference timezone
+func (q EventsQuery) On(day time.Time) EventsQuery {
+	return q.Between(day, day)
+}
+
+//WithBookingStatus keeps only bookings with status, empty keeps all
+func (q EventsQuery) WithBookingStatus(status string) EventsQuery {
+	q.bookingStatus = status
+	return q
+}
+
+//WithActiveTrucks limits the query to events with trucks booked
+func (q EventsQuery) WithActiveTrucks(active bool) EventsQuery {
+	q.activeTrucks = active
+	return q
+}
+
+//IncludeBookings controls whether events carry their bookings
+func (q EventsQuery) IncludeBookings(include bool) EventsQuery {
+	q.includeBookings = include
+	return q
+}
+
+//IncludeLocations controls whether events carry their location
+func (q EventsQuery) IncludeLocations(include bool) EventsQuery {
+	q.includeLocations = include
+	return q
+}
+
+//Page selects the page to fetch, walks start at it. Zero means the first page
+func (q EventsQuery) Page(page int) EventsQuery {
+	q.page = page
+	return q
+}
+
+//Locations returns the location uids the query is limited to
+func (q EventsQuery) Locations() []int {
+	return append([]int(nil), q.locations...)
+}
+
+//Trucks returns the truck ids the query is limited to
+func (q EventsQuery) Trucks() []string {
+	return append([]string(nil), q.trucks...)
+}
+
+//Window returns the start and end dates of the query, zero when open
+func (q EventsQuery) Window() (time.Time, time.Time) {
+	return q.start, q.end
+}
+
+//Validate reports the first problem with the query
+func (q EventsQuery) Validate() error {
+	if q.page < 0 {
+		return fmt.Errorf("Invalid Request: page %d is negative", q.page)
+	}
+	for _, uid := range q.locations {
+		if uid <= 0 {
+			return fmt.Errorf("Invalid Request: location %d is not a valid uid", uid)
+		}
+	}
+	for _, id := range q.trucks {
+		if len(id) == 0 {
+			return fmt.Errorf("Invalid Request: truck id is empty")
+		}
+	}
+	if !q.start.IsZero() && !q.end.IsZero() && startOfDay(q.end).Before(startOfDay(q.start)) {
+		return fmt.Errorf("Invalid Request: end date %s is before start date %s",
+			q.end.In(Timezone()).Format(dateLayout), q.start.In(Timezone()).Format(dateLayout))
+	}
+	switch q.bookingStatus {
+	case "", BookingApproved, BookingPending, BookingCancelled:
+	default:
+		return fmt.Errorf("Invalid Request: unknown booking status %q", q.bookingStatus)
+	}
+	return nil
+}
+
+//Matches reports whether event falls inside the location, truck and date filters of the query.
+//Booking status and include flags shape an event rather than select it, see Apply
+func (q EventsQuery) Matches(e Event) bool {
+	if len(q.locations) > 0 && !containsInt(q.locations, e.Location.UID) {
+		return false
+	}
+	if len(q.trucks) > 0 {
+		found := false
+		for _, id := range q.trucks {
+			if e.HasTruck(id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !q.start.IsZero() && !e.EndTime.After(startOfDay(q.start)) {
+		return false
+	}
+	if !q.end.IsZero() && !e.StartTime.Before(startOfDay(q.end).AddDate(0, 0, 1)) {
+		return false
+	}
+	if q.activeTrucks && len(e.Bookings) == 0 {
+		return false
+	}
+	return true
+}
+
+//Apply selects the events matching the query the way the api does: bookings are filtered by
+//status before truck and active truck filters look at them, and bookings and locations are
+//dropped unless included
+func (q EventsQuery) Apply(events []Event) []Event {
+	var selected []Event
+	for _, e := range events {
+		if q.bookingStatus != "" {
+			var bookings []Booking
+			for _, b := range e.Bookings {
+				if b.Status == q.bookingStatus {
+					bookings = append(bookings, b)
+				}
+			}
+			e.Bookings = bookings
+		}
+		if !q.Matches(e) {
+			continue
+		}
+		if !q.includeBookings {
+			e.Bookings = nil
+		}
+		if !q.includeLocations {
+			e.Location = Location{}
+		}
+		selected = append(selected, e)
+	}
+	return selected
+}
+
+//Values returns the query as url parameters
+func (q EventsQuery) Values() url.Values {
+	v := url.Values{}
+	page := q.page
+	if page < 1 {
+		page = 1
+	}
+	v.Set("page", strconv.Itoa(page))
+	if len(q.locations) > 0 {
+		ids := make([]string, len(q.locations))
+		for i, uid := range q.locations {
+			ids[i] = strconv.Itoa(uid)
+		}
+		v.Set("for_locations", strings.Join(ids, ","))
+	}
+	if len(q.trucks) > 0 {
+		v.Set("for_trucks", strings.Join(q.trucks, ","))
+	}
+	if !q.start.IsZero() {
+		v.Set("start_date", q.start.In(Timezone()).Format(dateLayout))
+	}
+	if !q.end.IsZero() {
+		v.Set("end_date", q.end.In(Timezone()).Format(dateLayout))
+	}
+	if q.bookingStatus != "" {
+		v.Set("with_booking_status", q.bookingStatus)
+	}
+	if q.activeTrucks {
+		v.Set("with_active_trucks", "true")
+	}
+	if q.includeBookings {
+		v.Set("include_bookings", "true")
+	}
+	if q.includeLocations {
+		v.Set("include_locations", "true")
+	}
+	return v
+}
+
+func (q EventsQuery) toQueryString() string {
+	return "?" + q.Values().Encode()
+}
+
+//ParseEventsQuery reads a query from url parameters as sent by Values
+func ParseEventsQuery(v url.Values) (EventsQuery, error) {
+	q := EventsQuery{
+		bookingStatus:    v.Get("with_booking_status"),
+		activeTrucks:     parseFlag(v.Get("with_active_trucks")),
+		includeBookings:  parseFlag(v.Get("include_bookings")),
+		includeLocations: parseFlag(v.Get("include_locations")),
+		page:             1,
+	}
+	if page := v.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil {
+			return q, fmt.Errorf("Invalid Request: page %q is not a number", page)
+		}
+		q.page = n
+	}
+	for _, id := range splitList(v.Get("for_locations")) {
+		uid, err := strconv.Atoi(id)
+		if err != nil {
+			return q, fmt.Errorf("Invalid Request: location %q is not a number", id)
+		}
+		q.locations = append(q.locations, uid)
+	}
+	q = q.ForTrucks(splitList(v.Get("for_trucks"))...)
+	var err error
+	if q.start, err = parseDate(v.Get("start_date")); err != nil {
+		return q, err
+	}
+	if q.end, err = parseDate(v.Get("end_date")); err != nil {
+		return q, err
+	}
+	return q, q.Validate()
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, value, Timezone())
+	if err != nil {
+		return t, fmt.Errorf("Invalid Request: date %q is not YYYY-MM-DD", value)
+	}
+	return t, nil
+}
+
+func parseFlag(value string) bool {
+	b, _ := strconv.ParseBool(value)
+	return b
+}
+
+//GetEvents gets a page of events matching query
+func (p Proxy) GetEvents(query EventsQuery) (LocationEventsResponse, error) {
+	return p.GetEventsContext(context.Background(), query)
+}
+
+//GetEventsContext gets a page of events matching query with a custom context
+func (p Proxy) GetEventsContext(ctx context.Context, query EventsQuery) (LocationEventsResponse, error) {
+	var r LocationEventsResponse
+	if err := query.Validate(); err != nil {
+		return r, err
+	}
+	err := p.get(ctx, "/api/events", query.toQueryString(), &r)
+	return r, err
+}
+
+//WalkEvents calls fn for every page of events matching query, starting at the query page
+func (p Proxy) WalkEvents(query EventsQuery, fn func(LocationEventsResponse) error) error {
+	return p.WalkEventsContext(context.Background(), query, fn)
+}
+
+//WalkEventsContext calls fn for every page of events matching query with a custom context
+func (p Proxy) WalkEventsContext(ctx context.Context, query EventsQuery, fn func(LocationEventsResponse) error) error {
+	return walkEvents(ctx, p, p.MaxPages, query, fn)
+}
+
+//GetAllEvents gets events matching query across all pages. When the page cap is reached the events
+//fetched so far are returned along with ErrPageLimit
+func (p Proxy) GetAllEvents(query EventsQuery) ([]Event, error) {
+	return p.GetAllEventsContext(context.Background(), query)
+}
+
+//GetAllEventsContext gets events matching query across all pages with a custom context
+func (p Proxy) GetAllEventsContext(ctx context.Context, query EventsQuery) ([]Event, error) {
+	return getAllEvents(ctx, p, p.MaxPages, query)
+}
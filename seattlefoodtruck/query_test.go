@@ -0,0 +1,107 @@
+package seattlefoodtruck
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsQueryValues(t *testing.T) {
+	day := time.Date(2018, 5, 1, 23, 30, 0, 0, time.UTC) //May 1st 4:30PM in seattle
+	q := NewEventsQuery().ForLocations(44, 52).ForTrucks(" Marination ", "nosh").
+		Between(day, day.AddDate(0, 0, 2)).WithBookingStatus(BookingApproved).WithActiveTrucks(true).
+		IncludeLocations(false).Page(2)
+	want := "end_date=2018-05-03&for_locations=44%2C52&for_trucks=marination%2Cnosh&include_bookings=true" +
+		"&page=2&start_date=2018-05-01&with_active_trucks=true&with_booking_status=approved"
+	if got := q.Values().Encode(); got != want {
+		t.Errorf("Expected '%v' got '%v'", want, got)
+	}
+	parsed, err := ParseEventsQuery(q.Values())
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if !reflect.DeepEqual(parsed.Values(), q.Values()) {
+		t.Errorf("Expected round trip to keep '%v' got '%v'", q.Values().Encode(), parsed.Values().Encode())
+	}
+}
+
+func TestLocationEventsRequestQuery(t *testing.T) {
+	want, _ := url.ParseQuery("page=1&for_locations=44&with_active_trucks=true&include_bookings=true" +
+		"&include_locations=true&with_booking_status=approved")
+	if got := (LocationEventsRequest{}).Query().Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected '%v' got '%v'", want.Encode(), got.Encode())
+	}
+}
+
+func TestEventsQueryValidate(t *testing.T) {
+	day := time.Date(2018, 5, 2, 12, 0, 0, 0, Timezone())
+	cases := []struct {
+		name  string
+		query EventsQuery
+		err   string
+	}{
+		{"valid", NewEventsQuery().ForLocations(44).On(day), ""},
+		{"zero value", EventsQuery{}, ""},
+		{"negative page", NewEventsQuery().Page(-1), "page"},
+		{"bad location", NewEventsQuery().ForLocations(0), "location"},
+		{"empty truck", NewEventsQuery().ForTrucks(" "), "truck"},
+		{"reversed window", NewEventsQuery().Between(day, day.AddDate(0, 0, -1)), "before start date"},
+		{"unknown status", NewEventsQuery().WithBookingStatus("paid"), "booking status"},
+	}
+	for _, c := range cases {
+		err := c.query.Validate()
+		if c.err == "" && err != nil {
+			t.Errorf("%s: expected no error got '%v'", c.name, err)
+		} else if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%s: expected error about %s got '%v'", c.name, c.err, err)
+		}
+	}
+	if _, err := ParseEventsQuery(url.Values{"start_date": {"May 1"}}); err == nil {
+		t.Errorf("Expected an error for a malformed date")
+	}
+}
+
+func TestEventsQueryApply(t *testing.T) {
+	lunch := time.Date(2018, 5, 1, 11, 0, 0, 0, Timezone())
+	booking := func(truck, status string) Booking {
+		return Booking{Status: status, Truck: FoodTruck{ID: truck}}
+	}
+	events := []Event{
+		{ID: 1, StartTime: lunch, EndTime: lunch.Add(3 * time.Hour), Location: Location{UID: 44},
+			Bookings: []Booking{booking("marination", BookingApproved), booking("nosh", BookingCancelled)}},
+		{ID: 2, StartTime: lunch.AddDate(0, 0, 1), EndTime: lunch.AddDate(0, 0, 1).Add(3 * time.Hour), Location: Location{UID: 52},
+			Bookings: []Booking{booking("nosh", BookingApproved)}},
+		{ID: 3, StartTime: lunch.AddDate(0, 0, 2), EndTime: lunch.AddDate(0, 0, 2).Add(3 * time.Hour), Location: Location{UID: 88}},
+	}
+	ids := func(events []Event) []int {
+		var ids []int
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+	cases := []struct {
+		name  string
+		query EventsQuery
+		want  []int
+	}{
+		{"all", NewEventsQuery(), []int{1, 2, 3}},
+		{"locations", NewEventsQuery().ForLocations(52, 88), []int{2, 3}},
+		{"day", NewEventsQuery().On(lunch.AddDate(0, 0, 1)), []int{2}},
+		{"open ended", NewEventsQuery().Between(lunch.AddDate(0, 0, 1), time.Time{}), []int{2, 3}},
+		{"active trucks", NewEventsQuery().WithActiveTrucks(true), []int{1, 2}},
+		{"approved nosh", NewEventsQuery().ForTrucks("nosh").WithBookingStatus(BookingApproved), []int{2}},
+		{"any nosh", NewEventsQuery().ForTrucks("nosh"), []int{1, 2}},
+	}
+	for _, c := range cases {
+		if got := ids(c.query.Apply(events)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: expected events %v got %v", c.name, c.want, got)
+		}
+	}
+	got := NewEventsQuery().IncludeBookings(false).IncludeLocations(false).Apply(events)
+	if got[0].Bookings != nil || got[0].Location.UID != 0 {
+		t.Errorf("Expected bookings and locations to be dropped got %+v", got[0])
+	}
+}
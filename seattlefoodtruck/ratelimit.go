@@ -0,0 +1,69 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//DefaultRequestsPerSecond is the request budget of a proxy created by NewProxy, shared by all copies
+//of it so the bot stays polite to seattlefoodtruck.com however many people ask at once
+const DefaultRequestsPerSecond = 5
+
+//RateLimiter spaces requests so no more than a budget per second reach the api, allowing short
+//bursts. It is safe for concurrent use
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	//next is when the bucket would be empty again if no more requests came
+	next time.Time
+}
+
+//NewRateLimiter allows perSecond requests per second with bursts of up to burst requests
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    burst,
+	}
+}
+
+//Wait blocks until a request may be made or ctx is done. It fails straight away when ctx would
+//expire before the request could go out
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	next := l.next
+	if next.Before(now) {
+		next = now
+	}
+	delay := next.Sub(now) - time.Duration(l.burst-1)*l.interval
+	if delay < 0 {
+		delay = 0
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		l.mu.Unlock()
+		return fmt.Errorf("rate limit wait of %v exceeds the deadline: %w", delay, context.DeadlineExceeded)
+	}
+	l.next = next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllowsBurstThenSpacesRequests(t *testing.T) {
+	l := NewRateLimiter(50, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Expected no error got '%v'", err)
+		}
+		if i == 1 && time.Since(start) > 15*time.Millisecond {
+			t.Errorf("Expected the first 2 requests to go out at once, took %v", time.Since(start))
+		}
+	}
+	//2 requests in the burst, then 2 more at 20ms intervals
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Expected requests past the burst to be spaced out, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterGivesUpBeforeDeadline(t *testing.T) {
+	l := NewRateLimiter(1, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := l.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error got '%v'", err)
+	}
+	if time.Since(start) > 5*time.Millisecond {
+		t.Errorf("Expected to give up straight away, took %v", time.Since(start))
+	}
+}
+
+func TestNilRateLimiterDoesNotWait(t *testing.T) {
+	var l *RateLimiter
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("Expected no error got '%v'", err)
+	}
+}
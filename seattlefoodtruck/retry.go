@@ -0,0 +1,112 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//DefaultRetryPolicy retries a request up to twice with a short jittered backoff
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+//RetryPolicy controls how requests that failed with a network error, 429 or a 5xx status are retried.
+//Only GET requests are sent by the proxy so every request is safe to retry
+type RetryPolicy struct {
+	//MaxAttempts is the total number of attempts including the first one, values below 2 disable retries
+	MaxAttempts int
+	//BaseDelay is the backoff before the first retry, it doubles on every further retry
+	BaseDelay time.Duration
+	//MaxDelay caps the backoff. When the api asks for a longer Retry-After than this we give up instead
+	MaxDelay time.Duration
+	//OnRetry is called before waiting for the next attempt
+	OnRetry func(RetryEvent)
+}
+
+//RetryEvent describes a retry that is about to happen
+type RetryEvent struct {
+	//Ctx is the context of the call being retried, e.g. to log with the caller's fields
+	Ctx      context.Context
+	Endpoint string
+	URL      string
+	//Attempt is the attempt that just failed, starting at 1
+	Attempt int
+	//Delay is how long we wait before the next attempt
+	Delay time.Duration
+	//Err is the error of the failed attempt
+	Err error
+}
+
+//next reports whether the call should be retried after attempt failed with err and how long to wait
+func (rp RetryPolicy) next(ctx context.Context, attempt int, err error) (time.Duration, bool) {
+	if attempt >= rp.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+		return 0, false
+	}
+	delay := rp.backoff(attempt)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		delay = apiErr.RetryAfter
+		if rp.MaxDelay > 0 && delay > rp.MaxDelay {
+			return 0, false
+		}
+	}
+	//don't start waiting for an attempt we have no time left to make
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+//backoff returns the jittered exponential backoff after attempt, between half and all of the full delay
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	delay := rp.BaseDelay
+	for i := 1; i < attempt && (rp.MaxDelay <= 0 || delay < rp.MaxDelay); i++ {
+		delay *= 2
+	}
+	if rp.MaxDelay > 0 && delay > rp.MaxDelay {
+		delay = rp.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+//retryable reports whether err is a transient failure worth another attempt
+func retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == 0 {
+		//network failures and attempts that hit the per attempt timeout
+		return apiErr.Err != nil && !errors.Is(apiErr.Err, context.Canceled)
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
+
+//parseRetryAfter parses a Retry-After header given either in seconds or as an http date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
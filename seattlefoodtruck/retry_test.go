@@ -0,0 +1,165 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryOnUnavailable(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"pagination":{"page":1,"total_pages":1,"total_count":0},"neighborhoods":[]}`))
+	}))
+	defer s.Close()
+
+	var retries []RetryEvent
+	p, _ := NewProxy(s.URL)
+	p.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, OnRetry: func(e RetryEvent) {
+		retries = append(retries, e)
+	}}
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "caller")
+	_, err := p.GetNeighborhoodsContext(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if calls != 3 || len(retries) != 2 {
+		t.Fatalf("Expected 3 calls and 2 retries got %d and %d", calls, len(retries))
+	}
+	if retries[0].Attempt != 1 || retries[0].Endpoint != "/api/neighborhoods" || !errors.Is(retries[0].Err, ErrUnavailable) ||
+		retries[0].Ctx == nil || retries[0].Ctx.Value(key{}) != "caller" {
+		t.Errorf("Unexpected retry event %+v", retries[0])
+	}
+}
+
+func TestOnRequestReportsEveryAttempt(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"pagination":{"page":1,"total_pages":1,"total_count":0},"neighborhoods":[]}`))
+	}))
+	defer s.Close()
+
+	var requests []RequestEvent
+	p, _ := NewProxy(s.URL)
+	p.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	p.OnRequest = func(ctx context.Context, e RequestEvent) { requests = append(requests, e) }
+	if _, err := p.GetNeighborhoods(); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests got %+v", requests)
+	}
+	if requests[0].StatusCode != http.StatusServiceUnavailable || !errors.Is(requests[0].Err, ErrUnavailable) {
+		t.Errorf("Unexpected first request %+v", requests[0])
+	}
+	if requests[1].Endpoint != "/api/neighborhoods" || requests[1].Route != "/api/neighborhoods" || requests[1].StatusCode != http.StatusOK || requests[1].Err != nil {
+		t.Errorf("Unexpected second request %+v", requests[1])
+	}
+	//answered from the cache, the api is not asked again
+	p.GetNeighborhoods()
+	if len(requests) != 2 {
+		t.Errorf("Expected cache hits not to be reported as requests got %d", len(requests))
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer s.Close()
+
+	var delays []time.Duration
+	p, _ := NewProxy(s.URL)
+	p.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second, OnRetry: func(e RetryEvent) {
+		delays = append(delays, e.Delay)
+	}}
+	_, err := p.GetNeighborhoods()
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected '%v' got '%v'", ErrRateLimited, err)
+	}
+	if calls != 2 || len(delays) != 1 || delays[0] != time.Second {
+		t.Errorf("Expected one retry after 1s got %d calls and delays %v", calls, delays)
+	}
+
+	//a Retry-After beyond MaxDelay is not worth waiting for
+	calls = 0
+	p.Retry.MaxDelay = 500 * time.Millisecond
+	p.GetNeighborhoods()
+	if calls != 1 {
+		t.Errorf("Expected 1 call got %d", calls)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	calls := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+
+	p, _ := NewProxy(s.URL)
+	p.Retry.BaseDelay = time.Millisecond
+	p.GetNeighborhoods()
+	if calls != 1 {
+		t.Errorf("Expected 1 call got %d", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-3":                            0,
+		"Tue, 01 May 2018 12:00:30 GMT": 30 * time.Second,
+		"Tue, 01 May 2018 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) expected %v got %v", value, want, got)
+		}
+	}
+}
+
+func TestOnRequestRoutesTrucks(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"marination","name":"Marination"}`))
+	}))
+	defer s.Close()
+
+	var requests []RequestEvent
+	p, _ := NewProxy(s.URL)
+	p.OnRequest = func(ctx context.Context, e RequestEvent) { requests = append(requests, e) }
+	for _, id := range []string{"marination", "off-the-rez"} {
+		if _, err := p.GetTruck(id); err != nil {
+			t.Fatalf("Expected no error got '%v'", err)
+		}
+	}
+	if len(requests) != 2 || requests[1].Endpoint != "/api/trucks/off-the-rez" {
+		t.Fatalf("Unexpected requests %+v", requests)
+	}
+	for _, e := range requests {
+		if e.Route != "/api/trucks/{id}" {
+			t.Errorf("Expected every truck under one route got %+v", e)
+		}
+	}
+}
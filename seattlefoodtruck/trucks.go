@@ -0,0 +1,182 @@
+package seattlefoodtruck
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+//Truck is a food truck profile
+type Truck struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Trailer        bool     `json:"trailer"`
+	FoodCategories []string `json:"food_categories"`
+	ID             string   `json:"id"`
+	UID            int      `json:"uid"`
+	FeaturedPhoto  string   `json:"featured_photo"`
+	Photos         []string `json:"photos,omitempty"`
+	Phone          string   `json:"phone,omitempty"`
+	Email          string   `json:"email,omitempty"`
+	Website        string   `json:"website,omitempty"`
+	Facebook       string   `json:"facebook,omitempty"`
+	Twitter        string   `json:"twitter,omitempty"`
+	Instagram      string   `json:"instagram,omitempty"`
+}
+
+//TrucksResponse is a page of trucks
+type TrucksResponse struct {
+	Pagination Pagination `json:"pagination"`
+	Trucks     []Truck    `json:"trucks"`
+}
+
+//TruckRequest request to get a page of trucks
+type TruckRequest struct {
+	Page int
+}
+
+func (tr TruckRequest) toQueryString() string {
+	if tr.Page <= 1 {
+		return "?page=1"
+	}
+	return "?page=" + strconv.Itoa(tr.Page)
+}
+
+//TruckEventsRequest is request for the events a truck is booked at
+type TruckEventsRequest struct {
+	Truck string
+	Page  int
+}
+
+//NewTruckEventsRequest returns a new request initialized
+func NewTruckEventsRequest(truck string, page int) TruckEventsRequest {
+	return TruckEventsRequest{
+		Truck: truck,
+		Page:  page,
+	}
+}
+
+//withDefaults fills in the fields the caller left empty
+func (ter TruckEventsRequest) withDefaults() TruckEventsRequest {
+	ter.Truck = strings.ToLower(strings.TrimSpace(ter.Truck))
+	if ter.Page == 0 {
+		ter.Page = 1
+	}
+	return ter
+}
+
+//Query returns the events query the request stands for: events the truck has an approved booking
+//at, including bookings and locations
+func (ter TruckEventsRequest) Query() EventsQuery {
+	ter = ter.withDefaults()
+	return NewEventsQuery().ForTrucks(ter.Truck).Page(ter.Page).WithBookingStatus(BookingApproved)
+}
+
+func (ter TruckEventsRequest) toQueryString() string {
+	return ter.Query().toQueryString()
+}
+
+//truckRoute is the route template of truckEndpoint, shared by every truck
+const truckRoute = "/api/trucks/{id}"
+
+//truckEndpoint returns the api path of a single truck
+func truckEndpoint(id string) string {
+	return "/api/trucks/" + url.PathEscape(strings.ToLower(strings.TrimSpace(id)))
+}
+
+//route returns the route template of an api path
+func route(endpoint string) string {
+	if strings.HasPrefix(endpoint, "/api/trucks/") {
+		return truckRoute
+	}
+	return endpoint
+}
+
+//GetTruck gets the profile of the truck with the given id, e.g. marination
+func (p Proxy) GetTruck(id string) (Truck, error) {
+	return p.GetTruckContext(context.Background(), id)
+}
+
+//GetTruckContext gets the profile of the truck with the given id with a custom context
+func (p Proxy) GetTruckContext(ctx context.Context, id string) (Truck, error) {
+	var t Truck
+	if strings.TrimSpace(id) == "" {
+		return t, fmt.Errorf("Invalid Parameter: truck id is missing")
+	}
+	err := p.get(ctx, truckEndpoint(id), "", &t)
+	return t, err
+}
+
+//GetTrucks gets a page of trucks
+func (p Proxy) GetTrucks(request *TruckRequest) (TrucksResponse, error) {
+	return p.GetTrucksContext(context.Background(), request)
+}
+
+//GetTrucksContext gets a page of trucks with a custom context
+func (p Proxy) GetTrucksContext(ctx context.Context, request *TruckRequest) (TrucksResponse, error) {
+	var tr TrucksResponse
+	if request == nil {
+		return tr, fmt.Errorf("Invalid Request")
+	}
+	err := p.get(ctx, "/api/trucks", request.toQueryString(), &tr)
+	return tr, err
+}
+
+//GetTruckEvents gets events the truck in request is booked at
+func (p Proxy) GetTruckEvents(request *TruckEventsRequest) (LocationEventsResponse, error) {
+	return p.GetTruckEventsContext(context.Background(), request)
+}
+
+//GetTruckEventsContext gets events the truck in request is booked at with a custom context
+func (p Proxy) GetTruckEventsContext(ctx context.Context, request *TruckEventsRequest) (LocationEventsResponse, error) {
+	var r LocationEventsResponse
+	if request == nil || len(strings.TrimSpace(request.Truck)) == 0 {
+		return r, fmt.Errorf("Invalid Request")
+	}
+	err := p.get(ctx, "/api/events", request.toQueryString(), &r)
+	return r, err
+}
+
+//WalkTrucks calls fn for every page of trucks
+func (p Proxy) WalkTrucks(fn func(TrucksResponse) error) error {
+	return p.WalkTrucksContext(context.Background(), fn)
+}
+
+//WalkTrucksContext calls fn for every page of trucks with a custom context
+func (p Proxy) WalkTrucksContext(ctx context.Context, fn func(TrucksResponse) error) error {
+	return walkTrucks(ctx, p, p.MaxPages, fn)
+}
+
+//GetAllTrucks gets trucks across all pages. When the page cap is reached the trucks fetched so far
+//are returned along with ErrPageLimit
+func (p Proxy) GetAllTrucks() ([]Truck, error) {
+	return p.GetAllTrucksContext(context.Background())
+}
+
+//GetAllTrucksContext gets trucks across all pages with a custom context
+func (p Proxy) GetAllTrucksContext(ctx context.Context) ([]Truck, error) {
+	return getAllTrucks(ctx, p, p.MaxPages)
+}
+
+//WalkTruckEvents calls fn for every page of events matching request, starting at request.Page
+func (p Proxy) WalkTruckEvents(request *TruckEventsRequest, fn func(LocationEventsResponse) error) error {
+	return p.WalkTruckEventsContext(context.Background(), request, fn)
+}
+
+//WalkTruckEventsContext calls fn for every page of events matching request with a custom context
+func (p Proxy) WalkTruckEventsContext(ctx context.Context, request *TruckEventsRequest, fn func(LocationEventsResponse) error) error {
+	return walkTruckEvents(ctx, p, p.MaxPages, request, fn)
+}
+
+//GetAllTruckEvents gets events matching request across all pages. When the page cap is reached the
+//events fetched so far are returned along with ErrPageLimit
+func (p Proxy) GetAllTruckEvents(request *TruckEventsRequest) ([]Event, error) {
+	return p.GetAllTruckEventsContext(context.Background(), request)
+}
+
+//GetAllTruckEventsContext gets events matching request across all pages with a custom context
+func (p Proxy) GetAllTruckEventsContext(ctx context.Context, request *TruckEventsRequest) ([]Event, error) {
+	return getAllTruckEvents(ctx, p, p.MaxPages, request)
+}
@@ -0,0 +1,172 @@
+//Package snapshot crawls seattlefoodtruck.com into versioned files the bot can answer from when the
+//site is slow or down, and that reproduce what the bot saw when debugging a report
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//Version is the snapshot format written by this package, snapshots of later versions are refused
+const Version = 1
+
+//fileLayout names snapshot files by when they were taken so they sort oldest first
+const fileLayout = "snapshot-20060102T150405Z.json"
+
+//Snapshot is the schedule as crawled at one point in time. The embedded fixture makes a snapshot
+//file loadable with seattlefoodtruck.LoadFixture too, e.g. by the emulator
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	//Source is the address of the api that was crawled
+	Source string `json:"source"`
+	//Start and End bound the days events were crawled for
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	//Partial is set when a list hit the page limit and is incomplete, Incomplete names those lists
+	Partial    bool     `json:"partial"`
+	Incomplete []string `json:"incomplete,omitempty"`
+	seattlefoodtruck.Fixture
+}
+
+//Crawl fetches every neighborhood, location and truck and the events between start and end. Lists
+//cut short by the page limit are kept and named in Incomplete
+func Crawl(ctx context.Context, client seattlefoodtruck.Client, source string, start time.Time, end time.Time) (Snapshot, error) {
+	s := Snapshot{Version: Version, CreatedAt: time.Now().UTC(), Source: source, Start: start, End: end}
+	partial := func(what string, err error) error {
+		if err == seattlefoodtruck.ErrPageLimit {
+			s.Partial = true
+			s.Incomplete = append(s.Incomplete, what)
+			return nil
+		}
+		return err
+	}
+	var err error
+	s.Neighborhoods, err = client.GetAllNeighborhoodsContext(ctx)
+	if err = partial("neighborhoods", err); err != nil {
+		return s, err
+	}
+	seen := make(map[int]bool)
+	for _, n := range s.Neighborhoods {
+		lr := seattlefoodtruck.LocationRequest{Page: 1, Neighborhood: n.ID}
+		locations, err := client.GetAllLocationsContext(ctx, &lr)
+		if err = partial("locations in "+n.ID, err); err != nil {
+			return s, err
+		}
+		for _, l := range locations {
+			if !seen[l.UID] {
+				seen[l.UID] = true
+				s.Locations = append(s.Locations, l)
+			}
+		}
+	}
+	s.Trucks, err = client.GetAllTrucksContext(ctx)
+	if err = partial("trucks", err); err != nil {
+		return s, err
+	}
+	s.Events, err = client.GetAllEventsContext(ctx, seattlefoodtruck.NewEventsQuery().Between(start, end))
+	if err = partial("events", err); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+//Write saves the snapshot in dir, named by when it was taken, and returns the file's path. The
+//file only appears once it is complete so a bot reading dir never sees half a snapshot
+func (s Snapshot) Write(dir string) (string, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, s.CreatedAt.UTC().Format(fileLayout))
+	return path, os.Rename(tmp.Name(), path)
+}
+
+//Load reads a snapshot file
+func Load(path string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("Invalid snapshot %s: %v", path, err)
+	}
+	if s.Version < 1 || s.Version > Version {
+		return s, fmt.Errorf("Unsupported snapshot version %d in %s", s.Version, path)
+	}
+	return s, nil
+}
+
+//List returns the snapshot files in dir, oldest first
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		if _, err := time.Parse(fileLayout, e.Name()); err == nil && !e.IsDir() {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+//Latest returns the path of the newest snapshot in dir
+func Latest(dir string) (string, error) {
+	paths, err := List(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("No snapshots in %s", dir)
+	}
+	return paths[len(paths)-1], nil
+}
+
+//Prune removes all but the newest keep snapshots in dir, keep below one removes nothing
+func Prune(dir string, keep int) error {
+	if keep < 1 {
+		return nil
+	}
+	paths, err := List(dir)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for len(paths) > keep {
+		if err := os.Remove(paths[0]); err != nil {
+			failed = append(failed, err.Error())
+		}
+		paths = paths[1:]
+	}
+	if len(failed) != 0 {
+		return fmt.Errorf("Could not remove old snapshots: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck/emulator"
+)
+
+func TestCrawl(t *testing.T) {
+	f := emulator.DefaultFixture().ShiftedTo(time.Now())
+	client := seattlefoodtruck.NewFakeClient(f)
+	start := time.Now()
+	s, err := Crawl(context.Background(), client, "fake", start, start.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if s.Version != Version || s.Partial {
+		t.Errorf("Expected a complete version %d snapshot got version %d partial %v", Version, s.Version, s.Partial)
+	}
+	if len(s.Neighborhoods) != len(f.Neighborhoods) || len(s.Trucks) != len(f.Trucks) {
+		t.Errorf("Expected %d neighborhoods and %d trucks got %d and %d",
+			len(f.Neighborhoods), len(f.Trucks), len(s.Neighborhoods), len(s.Trucks))
+	}
+	if len(s.Locations) == 0 || len(s.Events) == 0 {
+		t.Errorf("Expected locations and events got %d and %d", len(s.Locations), len(s.Events))
+	}
+	for _, e := range s.Events {
+		if e.EndTime.Before(start.AddDate(0, 0, -1)) || e.StartTime.After(start.AddDate(0, 0, 7)) {
+			t.Errorf("Expected events in the coming week got %v", e.StartTime)
+		}
+	}
+}
+
+func TestCrawlPartial(t *testing.T) {
+	client := seattlefoodtruck.NewFakeClient(emulator.DefaultFixture())
+	client.PageSize, client.MaxPages = 1, 1
+	start := time.Now()
+	s, err := Crawl(context.Background(), client, "fake", start, start)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if !s.Partial || len(s.Incomplete) == 0 || s.Incomplete[0] != "neighborhoods" {
+		t.Errorf("Expected the neighborhoods named as incomplete got partial %v %q", s.Partial, s.Incomplete)
+	}
+}
+
+func TestCrawlFails(t *testing.T) {
+	client := seattlefoodtruck.NewFakeClient(emulator.DefaultFixture())
+	client.SetError(seattlefoodtruck.ErrUnavailable)
+	if _, err := Crawl(context.Background(), client, "fake", time.Now(), time.Now()); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestWriteLoadLatest(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		s := Snapshot{Version: Version, CreatedAt: day.AddDate(0, 0, i), Source: "fake"}
+		s.Trucks = []seattlefoodtruck.Truck{{Name: "Marination", ID: "marination"}}
+		if _, err := s.Write(dir); err != nil {
+			t.Fatalf("Expected no error got '%v'", err)
+		}
+	}
+	//files that are not snapshots are ignored
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0644)
+
+	latest, err := Latest(dir)
+	if err != nil || filepath.Base(latest) != "snapshot-20180503T000000Z.json" {
+		t.Fatalf("Expected the May 3rd snapshot got %v, %v", latest, err)
+	}
+	s, err := Load(latest)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if !s.CreatedAt.Equal(day.AddDate(0, 0, 2)) || len(s.Trucks) != 1 {
+		t.Errorf("Expected the snapshot as written got %+v", s)
+	}
+	//snapshots are fixtures too
+	if f, err := seattlefoodtruck.LoadFixture(latest); err != nil || len(f.Trucks) != 1 {
+		t.Errorf("Expected snapshot to load as a fixture got %v, %v", f, err)
+	}
+
+	if err := Prune(dir, 1); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if paths, _ := List(dir); len(paths) != 1 || paths[0] != latest {
+		t.Errorf("Expected only the latest snapshot to be kept got %v", paths)
+	}
+}
+
+func TestLoadRejectsNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	os.WriteFile(path, []byte(`{"version": 2}`), 0644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "Unsupported snapshot version 2") {
+		t.Errorf("Expected unsupported version error got '%v'", err)
+	}
+}
+
+func TestLatestEmpty(t *testing.T) {
+	if _, err := Latest(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without snapshots")
+	}
+}
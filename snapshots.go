@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/snapshot"
+)
+
+//snapshotWatcher answers from the newest snapshot in a directory, picking up snapshots
+//foodtruck-snapshot writes while the bot runs. It is safe for concurrent use
+type snapshotWatcher struct {
+	dir  string
+	file *provider.File
+
+	mu sync.Mutex
+	//path is the snapshot file answers from
+	path string
+}
+
+//newSnapshotWatcher answers from the newest snapshot in dir
+func newSnapshotWatcher(dir string) (*snapshotWatcher, error) {
+	path, s, err := latestSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Answering from snapshot", "path", path, "age", time.Since(s.CreatedAt).Round(time.Minute))
+	f := provider.NewFileFromFixture(s.Fixture)
+	f.PhotoBase = provider.PhotoBucket
+	return &snapshotWatcher{dir: dir, file: f, path: path}, nil
+}
+
+//latestSnapshot loads the newest snapshot in dir
+func latestSnapshot(dir string) (string, snapshot.Snapshot, error) {
+	path, err := snapshot.Latest(dir)
+	if err != nil {
+		return "", snapshot.Snapshot{}, err
+	}
+	s, err := snapshot.Load(path)
+	return path, s, err
+}
+
+//refresh swaps in the newest snapshot when it is not the one answered from, on failure the current
+//one is kept
+func (w *snapshotWatcher) refresh() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	path, err := snapshot.Latest(w.dir)
+	if err != nil {
+		slog.Warn("Failed to find the latest snapshot, keeping the current one", "dir", w.dir, "error", err)
+		return err
+	}
+	if path == w.path {
+		return nil
+	}
+	s, err := snapshot.Load(path)
+	if err != nil {
+		slog.Warn("Failed to load the latest snapshot, keeping the current one", "path", path, "error", err)
+		return err
+	}
+	w.file.Swap(s.Fixture)
+	slog.Info("Answering from newer snapshot", "path", path, "previous", w.path, "age", time.Since(s.CreatedAt).Round(time.Minute))
+	w.path = path
+	return nil
+}
+
+//watch refreshes every interval until ctx is done
+func (w *snapshotWatcher) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.refresh()
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+	"github.com/rprakashg/foodtruck-slack-bot/snapshot"
+)
+
+func TestSnapshotWatcherRefresh(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC)
+	write := func(created time.Time, truck string) string {
+		s := snapshot.Snapshot{Version: snapshot.Version, CreatedAt: created, Source: "fake"}
+		s.Trucks = []seattlefoodtruck.Truck{{Name: truck, ID: truck}}
+		path, err := s.Write(dir)
+		if err != nil {
+			t.Fatalf("Expected no error got '%v'", err)
+		}
+		return path
+	}
+	write(day, "marination")
+
+	w, err := newSnapshotWatcher(dir)
+	if err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if _, err := w.file.Truck(context.Background(), "marination"); err != nil {
+		t.Fatalf("Expected the first snapshot got '%v'", err)
+	}
+
+	newer := write(day.AddDate(0, 0, 1), "nosh")
+	if err := w.refresh(); err != nil {
+		t.Fatalf("Expected no error got '%v'", err)
+	}
+	if _, err := w.file.Truck(context.Background(), "nosh"); err != nil || w.path != newer {
+		t.Errorf("Expected the newer snapshot got %v, '%v'", w.path, err)
+	}
+
+	//a broken snapshot leaves the bot answering from the last good one
+	os.WriteFile(filepath.Join(dir, "snapshot-20180503T000000Z.json"), []byte("{"), 0644)
+	if err := w.refresh(); err == nil {
+		t.Error("Expected an error for a broken snapshot")
+	}
+	if _, err := w.file.Truck(context.Background(), "nosh"); err != nil || w.path != newer {
+		t.Errorf("Expected the newer snapshot kept got %v, '%v'", w.path, err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+//botMetrics are what the bot reports on /metrics
+type botMetrics struct {
+	registry    *prometheus.Registry
+	apiRequests *prometheus.CounterVec
+	apiLatency  *prometheus.HistogramVec
+	commands    *prometheus.CounterVec
+	connections prometheus.Counter
+	digests     *prometheus.CounterVec
+	alerts      *prometheus.CounterVec
+}
+
+//newBotMetrics registers the bot's metrics in a new registry
+func newBotMetrics() *botMetrics {
+	m := &botMetrics{
+		registry: prometheus.NewRegistry(),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foodtruck_api_requests_total",
+			Help: "Requests sent to the food truck api by endpoint and status code, code is error when no response was received",
+		}, []string{"endpoint", "code"}),
+		apiLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "foodtruck_api_request_duration_seconds",
+			Help:    "How long the food truck api took to answer by endpoint",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foodtruck_bot_commands_total",
+			Help: "Slack commands answered by command, unknown counts messages the bot did not understand",
+		}, []string{"command"}),
+		connections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "foodtruck_slack_connections_total",
+			Help: "Times the bot connected to slack, more than one means it reconnected",
+		}),
+		digests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foodtruck_digest_posts_total",
+			Help: "Morning digests by result, success or failure",
+		}, []string{"result"}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foodtruck_change_alerts_total",
+			Help: "Schedule change alerts posted under the digest by result, success or failure",
+		}, []string{"result"}),
+	}
+	m.registry.MustRegister(m.apiRequests, m.apiLatency, m.commands, m.connections, m.digests, m.alerts)
+	return m
+}
+
+//observeRequest records a request the proxy sent to the api
+func (m *botMetrics) observeRequest(e seattlefoodtruck.RequestEvent) {
+	code := "error"
+	if e.StatusCode != 0 {
+		code = strconv.Itoa(e.StatusCode)
+	}
+	m.apiRequests.WithLabelValues(e.Route, code).Inc()
+	m.apiLatency.WithLabelValues(e.Route).Observe(e.Duration.Seconds())
+}
+
+//watchCache reports the cache counters of providers that cache responses
+func (m *botMetrics) watchCache(source provider.Provider) {
+	cached, ok := source.(provider.CacheReporter)
+	if !ok {
+		return
+	}
+	counters := []struct {
+		name  string
+		help  string
+		value func(seattlefoodtruck.CacheStats) uint64
+	}{
+		{"foodtruck_api_cache_hits_total", "Api responses answered from the cache", func(s seattlefoodtruck.CacheStats) uint64 { return s.Hits }},
+		{"foodtruck_api_cache_revalidations_total", "Cached api responses the api confirmed were unchanged", func(s seattlefoodtruck.CacheStats) uint64 { return s.Revalidations }},
+		{"foodtruck_api_cache_misses_total", "Api responses fetched because they were not cached", func(s seattlefoodtruck.CacheStats) uint64 { return s.Misses }},
+		{"foodtruck_api_coalesced_total", "Api requests that shared the response of an identical request in flight", func(s seattlefoodtruck.CacheStats) uint64 { return s.Coalesced }},
+	}
+	for _, c := range counters {
+		value := c.value
+		m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: c.name, Help: c.help},
+			func() float64 { return float64(value(cached.CacheStats())) }))
+	}
+}
+
+//serveStatus serves the bot's metrics, health and readiness on addr until ctx is done
+func serveStatus(ctx context.Context, addr string, m *botMetrics, h *health) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", h.serveHealthz)
+	mux.HandleFunc("/readyz", h.serveReadyz)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	slog.Info("Serving metrics and health checks", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Failed to serve metrics and health checks", "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rprakashg/foodtruck-slack-bot/provider"
+	"github.com/rprakashg/foodtruck-slack-bot/seattlefoodtruck"
+)
+
+func TestBotMetrics(t *testing.T) {
+	m := newBotMetrics()
+	source := provider.NewSeattle(seattlefoodtruck.NewFakeClient(todayFixture()))
+	m.watchCache(source)
+	b := newBot(source)
+	b.commands = m.commands
+
+	b.respond(context.Background(), "help")
+	b.respond(context.Background(), "show trucks at 44")
+	b.respond(context.Background(), "sing a song")
+	m.observeRequest(seattlefoodtruck.RequestEvent{Endpoint: "/api/events", Route: "/api/events", StatusCode: 200, Duration: 30 * time.Millisecond})
+	m.observeRequest(seattlefoodtruck.RequestEvent{Endpoint: "/api/events", Route: "/api/events", Err: errors.New("connection refused")})
+	m.observeRequest(seattlefoodtruck.RequestEvent{Endpoint: "/api/trucks/marination", Route: "/api/trucks/{id}", StatusCode: 200})
+
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	out := w.Body
+	for _, want := range []string{
+		`foodtruck_bot_commands_total{command="help"} 1`,
+		`foodtruck_bot_commands_total{command="show trucks"} 1`,
+		`foodtruck_bot_commands_total{command="unknown"} 1`,
+		`foodtruck_api_requests_total{code="200",endpoint="/api/events"} 1`,
+		`foodtruck_api_requests_total{code="error",endpoint="/api/events"} 1`,
+		`foodtruck_api_requests_total{code="200",endpoint="/api/trucks/{id}"} 1`,
+		`foodtruck_api_request_duration_seconds_count{endpoint="/api/events"} 2`,
+		"foodtruck_api_cache_hits_total 0",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected '%v' in '%v'", want, out.String())
+		}
+	}
+}